@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/nick-jones/gost/pkg/certs"
+)
+
+var certsCommand = &cli.Command{
+	Name:      "certs",
+	Usage:     "list the X.509 certificates and keys embedded in a binary",
+	ArgsUsage: "<binary>",
+	Flags: append([]cli.Flag{
+		formatFlag,
+		&cli.DurationFlag{
+			Name:  "expires-within",
+			Usage: "only list certificates expiring within this duration (e.g. 720h), exiting with status 1 if any are found",
+		},
+	}, scanFlags("all")...),
+	Action: runCerts,
+}
+
+func runCerts(c *cli.Context) error {
+	write, err := formatWriter(c, certs.WriteText, certs.WriteJSON)
+	if err != nil {
+		return err
+	}
+
+	filePath := c.Args().First()
+	results, err := scanFile(c, filePath)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	items, err := certs.Find(f, results)
+	if err != nil {
+		return fmt.Errorf("failed to find certificates: %w", err)
+	}
+
+	within := c.Duration("expires-within")
+	if within > 0 {
+		items = certs.ExpiringWithin(items, time.Now().Add(within))
+	}
+	if err := write(os.Stdout, items); err != nil {
+		return err
+	}
+	if within > 0 && len(items) > 0 {
+		return cli.Exit(fmt.Sprintf("%d certificates expire within %s", len(items), within), 1)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/nick-jones/gost/pkg/diff"
+)
+
+var diffCommand = &cli.Command{
+	Name:      "diff",
+	Usage:     "report strings added, removed or moved between 2 builds",
+	ArgsUsage: "<old binary> <new binary>",
+	Flags: append([]cli.Flag{
+		formatFlag,
+	}, scanFlags("all")...),
+	Action: runDiff,
+}
+
+func runDiff(c *cli.Context) error {
+	if c.NArg() != 2 {
+		return fmt.Errorf("expected 2 binaries, got %d", c.NArg())
+	}
+
+	write, err := formatWriter(c, diff.WriteText, diff.WriteJSON)
+	if err != nil {
+		return err
+	}
+
+	oldResults, err := scanFile(c, c.Args().Get(0))
+	if err != nil {
+		return fmt.Errorf("old binary: %w", err)
+	}
+	newResults, err := scanFile(c, c.Args().Get(1))
+	if err != nil {
+		return fmt.Errorf("new binary: %w", err)
+	}
+
+	return write(os.Stdout, diff.Compare(oldResults, newResults))
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/nick-jones/gost/pkg/embedfs"
+)
+
+var embedCommand = &cli.Command{
+	Name:  "embed",
+	Usage: "list or extract the go:embed file systems within a binary",
+	Subcommands: []*cli.Command{
+		{
+			Name:      "ls",
+			Usage:     "list the files of each embedded file system",
+			ArgsUsage: "<binary>",
+			Flags: []cli.Flag{
+				formatFlag,
+			},
+			Action: runEmbedList,
+		},
+		{
+			Name:      "extract",
+			Usage:     "write the files of each embedded file system to a directory, beneath a directory named after the variable",
+			ArgsUsage: "<binary> <dir>",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "fs",
+					Usage: "only extract the file system with this name (e.g. main.static)",
+				},
+			},
+			Action: runEmbedExtract,
+		},
+	},
+}
+
+func runEmbedList(c *cli.Context) error {
+	write, err := formatWriter(c, embedfs.WriteText, embedfs.WriteJSON)
+	if err != nil {
+		return err
+	}
+
+	fss, err := findEmbedded(c.Args().First())
+	if err != nil {
+		return err
+	}
+	return write(os.Stdout, fss)
+}
+
+func runEmbedExtract(c *cli.Context) error {
+	if c.NArg() != 2 {
+		return fmt.Errorf("expected a binary and a directory, got %d arguments", c.NArg())
+	}
+
+	fss, err := findEmbedded(c.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	name := c.String("fs")
+	extracted := 0
+	for _, fsys := range fss {
+		if name != "" && fsys.String() != name {
+			continue
+		}
+		// the name comes from the binary's symbols, so must not be allowed to escape the target directory
+		if !fs.ValidPath(fsys.String()) || strings.Contains(fsys.String(), `\`) {
+			return fmt.Errorf("invalid file system name: %q", fsys.String())
+		}
+		dir := filepath.Join(c.Args().Get(1), filepath.FromSlash(fsys.String()))
+		if err := embedfs.Extract(fsys, dir); err != nil {
+			return fmt.Errorf("failed to extract %s: %w", fsys, err)
+		}
+		fmt.Printf("%s → %s (%d files)\n", fsys, dir, len(fsys.Files))
+		extracted++
+	}
+	if name != "" && extracted == 0 {
+		return fmt.Errorf("no embedded file system named %s", name)
+	}
+	return nil
+}
+
+// findEmbedded locates the embedded file systems in the binary at the supplied path
+func findEmbedded(filePath string) ([]embedfs.FS, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	fss, err := embedfs.Find(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find embedded file systems: %w", err)
+	}
+	return fss, nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/nick-jones/gost/pkg/inventory"
+)
+
+var envCommand = &cli.Command{
+	Name:      "env",
+	Usage:     "list the environment variables read by a binary",
+	ArgsUsage: "<binary>",
+	Flags: append([]cli.Flag{
+		formatFlag,
+	}, scanFlags("all")...),
+	Action: runEnv,
+}
+
+func runEnv(c *cli.Context) error {
+	write, err := formatWriter(c, inventory.WriteText, inventory.WriteJSON)
+	if err != nil {
+		return err
+	}
+
+	results, err := scanFile(c, c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	return write(os.Stdout, inventory.ByCallee(results, inventory.EnvCallees...))
+}
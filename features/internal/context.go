@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/cucumber/godog"
@@ -23,6 +24,23 @@ func NewContext() *Context {
 }
 
 func (c *Context) aBinaryBuiltFromSourceFile(fileName string, src *godog.DocString) error {
+	return c.build(fileName, src, false)
+}
+
+func (c *Context) aBinaryBuiltWithInliningFromSourceFile(fileName string, src *godog.DocString) error {
+	return c.build(fileName, src, true)
+}
+
+func (c *Context) aStrippedBinaryBuiltFromSourceFile(fileName string, src *godog.DocString) error {
+	// -s omits the symbol table, -w omits DWARF
+	return c.build(fileName, src, false, "-ldflags", "-s -w")
+}
+
+func (c *Context) aPositionIndependentBinaryBuiltFromSourceFile(fileName string, src *godog.DocString) error {
+	return c.build(fileName, src, false, "-buildmode=pie")
+}
+
+func (c *Context) build(fileName string, src *godog.DocString, inlining bool, flags ...string) error {
 	goBin, err := exec.LookPath("go")
 	if err != nil {
 		return err
@@ -39,7 +57,13 @@ func (c *Context) aBinaryBuiltFromSourceFile(fileName string, src *godog.DocStri
 	}
 
 	// -gcflags '-l' disables inlining, which gives more reliable file/line information
-	cmd := exec.Command(goBin, "build", "-gcflags", "-l", "-o", filepath.Join(c.tempDir, "bin"), srcFile)
+	args := []string{"build"}
+	if !inlining {
+		args = append(args, "-gcflags", "-l")
+	}
+	args = append(args, flags...)
+	args = append(args, "-o", filepath.Join(c.tempDir, "bin"), srcFile)
+	cmd := exec.Command(goBin, args...)
 	cmd.Env = os.Environ()
 
 	if goOS := os.Getenv("GODOG_GOOS"); goOS != "" {
@@ -53,13 +77,33 @@ func (c *Context) aBinaryBuiltFromSourceFile(fileName string, src *godog.DocStri
 }
 
 func (c *Context) thatBinaryIsAnalysed() error {
+	return c.analyse()
+}
+
+func (c *Context) thatBinaryIsAnalysedWithTheStringTableGuessed() error {
+	return c.analyse(scan.WithStringTableGuessed())
+}
+
+func (c *Context) thatBinaryIsAnalysedInTheMainScope() error {
+	return c.analyse(scan.WithScope(scan.ScopeMain))
+}
+
+func (c *Context) thatBinaryIsAnalysedInTheDefaultScope() error {
+	return c.analyse(scan.WithScope(scan.ScopeMain, scan.ScopeDeps))
+}
+
+func (c *Context) thatBinaryIsAnalysedUsingDWARFLineInformation() error {
+	return c.analyse(scan.WithLineSource(scan.LinesDWARF))
+}
+
+func (c *Context) analyse(opts ...scan.Option) error {
 	f, err := os.Open(filepath.Join(c.tempDir, "bin"))
 	if err != nil {
 		return err
 	}
 	defer f.Close()
 
-	c.results, err = scan.Run(f)
+	c.results, err = scan.Run(f, opts...)
 	if err != nil {
 		return err
 	}
@@ -68,60 +112,147 @@ func (c *Context) thatBinaryIsAnalysed() error {
 }
 
 func (c *Context) theFollowingResultsAreReturned(table *godog.Table) error {
-	type summary struct {
-		val      string
-		fileRefs []string
-		symRefs  []string
+	return c.compareResults(table, false)
+}
+
+func (c *Context) onlyTheFollowingResultsAreReturned(table *godog.Table) error {
+	return c.compareResults(table, true)
+}
+
+// lineSuffix matches the line following a file, e.g. :12 of main.go:12
+var lineSuffix = regexp.MustCompile(`:\d+$`)
+
+// summary is a result (or an expected result) as the columns of a results table, each holding a value per reference
+type summary map[string][]string
+
+// columns summarise a reference for each optional column of a results table
+var columns = map[string]func(scan.Reference) string{
+	"File References": func(ref scan.Reference) string {
+		return fmt.Sprintf("%s:%d", filepath.Base(ref.File), ref.Line)
+	},
+	"Symbol References": func(ref scan.Reference) string {
+		return ref.SymbolName
+	},
+	"Callees": func(ref scan.Reference) string {
+		return ref.Callee
+	},
+	"Inline Stacks": inlineStack,
+	"Targets":       target,
+}
+
+// compareResults checks the results against those in the table. Unless exact, other results may also be present.
+// Only the columns present in the table are compared.
+func (c *Context) compareResults(table *godog.Table, exact bool) error {
+	expected := expectedResults(table)
+	actual := make(map[string]summary, len(c.results))
+	for _, res := range c.results {
+		actual[res.Value] = summarise(res)
 	}
 
+	if exact && len(actual) != len(expected) {
+		values := make([]string, 0, len(actual))
+		for val := range actual {
+			values = append(values, val)
+		}
+		return fmt.Errorf("expected %d results, actual %d: %q", len(expected), len(actual), values)
+	}
+
+	for val, exp := range expected {
+		act, found := actual[val]
+		if !found {
+			return fmt.Errorf("failed to find string with value %s", val)
+		}
+		for column, values := range exp {
+			if !matchStringSlice(values, act[column]) {
+				return fmt.Errorf("differing %s for %q, expected %v, actual %v", strings.ToLower(column), val, values, act[column])
+			}
+		}
+	}
+	return nil
+}
+
+// expectedResults reads the results from a table, keyed by value
+func expectedResults(table *godog.Table) map[string]summary {
 	expected := make(map[string]summary)
 	header := table.Rows[0].Cells
 	for _, row := range table.Rows[1:] {
-		var s summary
+		var (
+			val string
+			s   = make(summary)
+		)
 		for i, cell := range row.Cells {
-			switch header[i].Value {
-			case "String":
-				s.val = cell.Value
-			case "File References":
-				s.fileRefs = strings.Fields(cell.Value)
-			case "Symbol References":
-				s.symRefs = strings.Fields(cell.Value)
+			column := header[i].Value
+			if column == "String" {
+				val = cell.Value
+			} else if _, known := columns[column]; known {
+				s[column] = strings.Fields(cell.Value)
 			}
 		}
-		expected[s.val] = s
+		expected[val] = s
 	}
+	return expected
+}
 
-	actual := make(map[string]summary)
-	for _, res := range c.results {
-		s := summary{val: res.Value}
+// summarise summarises the references of a result for every column
+func summarise(res scan.Result) summary {
+	s := make(summary, len(columns))
+	for column, describe := range columns {
 		for _, ref := range res.Refs {
-			s.fileRefs = append(s.fileRefs, fmt.Sprintf("%s:%d", filepath.Base(ref.File), ref.Line))
-			s.symRefs = append(s.symRefs, ref.SymbolName)
+			s[column] = append(s[column], describe(ref))
 		}
-		actual[res.Value] = s
 	}
+	return s
+}
 
-	for _, exp := range expected {
-		act, found := actual[exp.val]
-		if !found {
-			return fmt.Errorf("failed to find string with value %s", exp.val)
-		}
-		if !equalStringSlice(exp.fileRefs, act.fileRefs) {
-			return fmt.Errorf("differing file references for %q, expected %v, actual %v", exp.val, exp.fileRefs, act.fileRefs)
-		}
-		if !equalStringSlice(exp.symRefs, act.symRefs) {
-			return fmt.Errorf("differing symbol references for %q, expected %v, actual %v", exp.val, exp.symRefs, act.symRefs)
+// inlineStack summarises the inline stack of a reference as function@file:line frames joined by <, or - if the
+// reference is not within inlined code
+func inlineStack(ref scan.Reference) string {
+	if len(ref.Inlined) == 0 {
+		return "-"
+	}
+	frames := make([]string, len(ref.Inlined))
+	for i, fr := range ref.Inlined {
+		frames[i] = fmt.Sprintf("%s@%s:%d", fr.Function, filepath.Base(fr.File), fr.Line)
+	}
+	return strings.Join(frames, "<")
+}
+
+// target summarises the target of a reference as expr or expr(type) for fields, or - if not known
+func target(ref scan.Reference) string {
+	switch {
+	case ref.Target == "":
+		return "-"
+	case ref.TargetType != "":
+		return fmt.Sprintf("%s(%s)", ref.Target, ref.TargetType)
+	default:
+		return ref.Target
+	}
+}
+
+// matchStringSlice returns true if each expected value matches the actual value in the same position
+func matchStringSlice(expected, actual []string) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+	for i := range expected {
+		if !matchValue(expected[i], actual[i]) {
+			return false
 		}
 	}
-	return nil
+	return true
 }
 
-func equalStringSlice(a, b []string) bool {
-	if len(a) != len(b) {
+// matchValue returns true if the expected value matches the actual value. Where the expected value (or a frame of an
+// inline stack) omits the line of a file, any line matches; lines within the standard library change between Go
+// releases, so are best left out.
+func matchValue(expected, actual string) bool {
+	expFrames, actFrames := strings.Split(expected, "<"), strings.Split(actual, "<")
+	if len(expFrames) != len(actFrames) {
 		return false
 	}
-	for i := range a {
-		if a[i] != b[i] {
+	for i, exp := range expFrames {
+		act := actFrames[i]
+		if exp != act && exp != lineSuffix.ReplaceAllString(act, "") {
 			return false
 		}
 	}
@@ -139,6 +270,14 @@ func (c *Context) RegisterHooks(sc *godog.ScenarioContext) {
 	})
 
 	sc.Step(`^a binary built from source file (.+):$`, c.aBinaryBuiltFromSourceFile)
+	sc.Step(`^a binary built with inlining from source file (.+):$`, c.aBinaryBuiltWithInliningFromSourceFile)
+	sc.Step(`^a stripped binary built from source file (.+):$`, c.aStrippedBinaryBuiltFromSourceFile)
+	sc.Step(`^a position-independent binary built from source file (.+):$`, c.aPositionIndependentBinaryBuiltFromSourceFile)
 	sc.Step(`^that binary is analysed$`, c.thatBinaryIsAnalysed)
+	sc.Step(`^that binary is analysed with the string table guessed$`, c.thatBinaryIsAnalysedWithTheStringTableGuessed)
+	sc.Step(`^that binary is analysed in the main scope$`, c.thatBinaryIsAnalysedInTheMainScope)
+	sc.Step(`^that binary is analysed in the default scope$`, c.thatBinaryIsAnalysedInTheDefaultScope)
+	sc.Step(`^that binary is analysed using DWARF line information$`, c.thatBinaryIsAnalysedUsingDWARFLineInformation)
 	sc.Step(`^the following results are returned:$`, c.theFollowingResultsAreReturned)
+	sc.Step(`^only the following results are returned:$`, c.onlyTheFollowingResultsAreReturned)
 }
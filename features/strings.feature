@@ -87,7 +87,8 @@ Feature: String Extraction
       | banana | main.go:11      | main.main         |
       | apple  | main.go:11      | main.main         |
 
-  Scenario: Local function call (latter reference to `banana` currently not found)
+  Scenario: Local function call
+    The comparison with `banana` is made against immediates, so is attributed to the string passed from main.
     Given a binary built from source file main.go:
     """
     package main
@@ -108,9 +109,9 @@ Feature: String Extraction
     """
     When that binary is analysed
     Then the following results are returned:
-      | String | File References | Symbol References |
-      | banana | main.go:4       | main.main         |
-      | apple  | main.go:5       | main.main         |
+      | String | File References      | Symbol References           |
+      | banana | main.go:4 main.go:11 | main.main main.doubleBanana |
+      | apple  | main.go:5            | main.main                   |
 
   Scenario: String into struct
     Given a binary built from source file main.go:
@@ -788,7 +789,11 @@ Feature: String Extraction
       | String | File References | Symbol References |
       | banana | main.go:10      | main.main         |
 
-  Scenario: Variable assignment (4) - PCLN gets this wrong
+  Scenario: Variable assignment (4) - references are attributed to the lines using the value
+    Go 1.20+ compilers load `banana` separately on each path: line 10 where the branch is not taken (passing it to
+    fmt.Println), and line 11 ahead of the concatenation. Earlier compilers shared a single load, attributed to line 11.
+    Neither is attributed to line 9, where the assignment is made.
+
     Given a binary built from source file main.go:
     """
     package main
@@ -807,7 +812,248 @@ Feature: String Extraction
     }
     """
     When that binary is analysed
+    Then the following results are returned:
+      | String | File References       | Symbol References   |
+      | banana | main.go:10 main.go:11 | main.main main.main |
+      | apple  | main.go:11            | main.main           |
+
+  Scenario: Map literal initialisation
+    Given a binary built from source file main.go:
+    """
+    package main
+
+    import "fmt"
+
+    var services = map[string]map[string]int{
+      "tcp": {
+        "ftp":   21,
+        "ftps":  990,
+        "http":  80,
+        "https": 443,
+        "imap2": 143,
+        "imap3": 220,
+        "imaps": 993,
+        "pop3":  110,
+      },
+    }
+
+    func main() {
+      fmt.Println(services["tcp"]["imap2"])
+    }
+    """
+    When that binary is analysed
+    Then the following results are returned:
+      | String | File References       | Symbol References         |
+      | tcp    | main.go:5 main.go:19  | main.map.init.0 main.main |
+      | ftp    | main.go:7             | main.map.init.0           |
+      | ftps   | main.go:8             | main.map.init.0           |
+      | http   | main.go:9             | main.map.init.0           |
+      | https  | main.go:10            | main.map.init.0           |
+      | imap2  | main.go:11 main.go:19 | main.map.init.0 main.main |
+      | imap3  | main.go:12            | main.map.init.0           |
+      | imaps  | main.go:13            | main.map.init.0           |
+      | pop3   | main.go:14            | main.map.init.0           |
+
+  Scenario: Stripped binary with the string table guessed
+    Given a stripped binary built from source file main.go:
+    """
+    package main
+
+    import "fmt"
+
+    func main() {
+      fmt.Println("banana", "apple")
+    }
+    """
+    When that binary is analysed with the string table guessed
     Then the following results are returned:
       | String | File References | Symbol References |
-      | banana | main.go:11      | main.main         |
-      | apple  | main.go:11      | main.main         |
+      | banana | main.go:6       | main.main         |
+      | apple  | main.go:6       | main.main         |
+
+  Scenario: Position-independent executable
+    Given a position-independent binary built from source file main.go:
+    """
+    package main
+
+    import "fmt"
+
+    func main() {
+      fmt.Println("banana", "apple")
+    }
+    """
+    When that binary is analysed
+    Then the following results are returned:
+      | String | File References | Symbol References |
+      | banana | main.go:6       | main.main         |
+      | apple  | main.go:6       | main.main         |
+
+  Scenario: Callee attribution
+    Given a binary built from source file main.go:
+    """
+    package main
+
+    import (
+      "errors"
+      "fmt"
+      "os"
+    )
+
+    func main() {
+      v := os.Getenv("MY_ENV_VAR")
+      fmt.Printf("value %s\n", v)
+      fmt.Println(errors.New("boom"))
+    }
+    """
+    When that binary is analysed
+    Then the following results are returned:
+      | String     | File References | Symbol References | Callees     |
+      | MY_ENV_VAR | main.go:10      | main.main         | os.Getenv   |
+      | boom       | main.go:12      | main.main         | errors.New  |
+
+  Scenario: Main scope excludes the standard library
+    Given a binary built from source file main.go:
+    """
+    package main
+
+    import (
+      "fmt"
+      "os"
+    )
+
+    func main() {
+      fmt.Println("banana", os.Getenv("HOME"))
+    }
+    """
+    When that binary is analysed in the main scope
+    Then only the following results are returned:
+      | String | File References | Symbol References |
+      | banana | main.go:9       | main.main         |
+      | HOME   | main.go:9       | main.main         |
+
+  Scenario: Inlined functions carry their inline stack
+    Given a binary built with inlining from source file main.go:
+    """
+    package main
+
+    import (
+      "fmt"
+      "os"
+    )
+
+    func greet(name string) string {
+      return "hello" + name
+    }
+
+    func welcome(name string) string {
+      return greet(name)
+    }
+
+    func main() {
+      fmt.Println(welcome(os.Args[0]), "banana")
+    }
+    """
+    When that binary is analysed in the main scope
+    Then the following results are returned:
+      | String | File References | Inline Stacks                                                       |
+      | hello  | main.go:9       | main.greet@main.go:9<main.welcome@main.go:13<main.main@main.go:17 |
+      | banana | main.go:17      | -                                                                   |
+
+  Scenario: Strings passed to inlined standard library functions belong to the caller
+    Lines within the standard library change between Go releases, so its files & frames are expected without a line,
+    which matches any line.
+
+    Given a binary built with inlining from source file main.go:
+    """
+    package main
+
+    import (
+      "flag"
+      "fmt"
+    )
+
+    func main() {
+      name := flag.String("name", "world", "who to greet")
+      loud := flag.Bool("loud", false, "shout the greeting")
+      flag.Parse()
+      fmt.Println(*name, *loud)
+    }
+    """
+    When that binary is analysed in the default scope
+    Then the following results are returned:
+      | String             | File References | Inline Stacks                           |
+      | name               | flag.go         | flag.String@flag.go<main.main@main.go:9 |
+      | world              | flag.go         | flag.String@flag.go<main.main@main.go:9 |
+      | who to greet       | flag.go         | flag.String@flag.go<main.main@main.go:9 |
+      | loud               | flag.go         | flag.Bool@flag.go<main.main@main.go:10  |
+      | shout the greeting | flag.go         | flag.Bool@flag.go<main.main@main.go:10  |
+
+  Scenario: File and line from the DWARF line table
+    The Go linker writes .debug_line from the same positions as the PCLN table, so the DWARF line table is no more
+    accurate for Go code. This is the "Variable assignment (4)" scenario, with the same results.
+
+    Given a binary built from source file main.go:
+    """
+    package main
+
+    import (
+      "os"
+      "fmt"
+    )
+
+    func main() {
+      x := "banana"
+      if len(os.Args[1]) > 0 {
+        x += "apple"
+      }
+      fmt.Println(x)
+    }
+    """
+    When that binary is analysed using DWARF line information
+    Then the following results are returned:
+      | String | File References       | Symbol References   |
+      | banana | main.go:10 main.go:11 | main.main main.main |
+      | apple  | main.go:11            | main.main           |
+
+  Scenario: Variables and fields strings are assigned to
+    Given a binary built from source file main.go:
+    """
+    package main
+
+    import (
+      "fmt"
+      "os"
+    )
+
+    type Config struct {
+      Name     string
+      Endpoint string
+    }
+
+    type Server struct {
+      ID   int
+      Opts Config
+    }
+
+    func serve(s *Server) {
+      fmt.Println(s.ID, s.Opts.Endpoint)
+    }
+
+    func main() {
+      var srv Server
+      srv.ID = len(os.Args)
+      srv.Opts.Endpoint = "https://stack.example.com"
+      serve(&srv)
+
+      endpoint := "https://fallback.example.com"
+      if len(os.Args) > 1 {
+        endpoint = os.Args[1]
+      }
+      fmt.Println(endpoint)
+    }
+    """
+    When that binary is analysed in the main scope
+    Then the following results are returned:
+      | String                       | File References | Targets                       |
+      | https://stack.example.com    | main.go:25      | srv.Opts.Endpoint(main.Config) |
+      | https://fallback.example.com | main.go:30      | endpoint                      |
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/nick-jones/gost/pkg/inventory"
+)
+
+var flagsCommand = &cli.Command{
+	Name:      "flags",
+	Usage:     "reconstruct the command-line flags defined by a binary",
+	ArgsUsage: "<binary>",
+	Flags: append([]cli.Flag{
+		formatFlag,
+	}, scanFlags("all")...),
+	Action: runFlags,
+}
+
+func runFlags(c *cli.Context) error {
+	write, err := formatWriter(c, inventory.WriteFlagsText, inventory.WriteFlagsJSON)
+	if err != nil {
+		return err
+	}
+
+	results, err := scanFile(c, c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	return write(os.Stdout, inventory.Flags(results))
+}
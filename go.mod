@@ -4,14 +4,15 @@ go 1.19
 
 require (
 	github.com/cucumber/godog v0.12.5
-	github.com/cucumber/messages-go/v16 v16.0.1
 	github.com/stretchr/testify v1.8.1
 	github.com/urfave/cli/v2 v2.20.3
+	golang.org/x/arch v0.4.0
 )
 
 require (
 	github.com/cpuguy83/go-md2man/v2 v2.0.2 // indirect
 	github.com/cucumber/gherkin-go/v19 v19.0.3 // indirect
+	github.com/cucumber/messages-go/v16 v16.0.1 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/gofrs/uuid v4.0.0+incompatible // indirect
 	github.com/hashicorp/go-immutable-radix v1.3.0 // indirect
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/nick-jones/gost/pkg/report"
+	"github.com/nick-jones/gost/pkg/scan"
+)
+
+var infoCommand = &cli.Command{
+	Name:      "info",
+	Usage:     "report the toolchain, modules and build settings recorded in a binary",
+	ArgsUsage: "<binary>",
+	Flags: []cli.Flag{
+		formatFlag,
+	},
+	Action: runInfo,
+}
+
+func runInfo(c *cli.Context) error {
+	write, err := formatWriter(c, writeInfoText, report.WriteBuildInfoJSON)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(c.Args().First())
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	bi, err := scan.ReadBuildInfo(f)
+	if err != nil {
+		return err
+	}
+	return write(os.Stdout, bi)
+}
+
+// writeInfoText writes the build information in the same layout as `go version -m`
+func writeInfoText(w io.Writer, bi *scan.BuildInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "go\t%s\n", bi.GoVersion)
+	if bi.Path != "" {
+		fmt.Fprintf(tw, "path\t%s\n", bi.Path)
+	}
+	if bi.Main.Path != "" {
+		writeInfoModule(tw, "mod", bi.Main)
+	}
+	for _, dep := range bi.Deps {
+		writeInfoModule(tw, "dep", dep)
+	}
+	for _, s := range bi.Settings {
+		fmt.Fprintf(tw, "build\t%s=%s\n", s.Key, s.Value)
+	}
+	return tw.Flush()
+}
+
+// writeInfoModule writes a single module, followed by its replacement (if any)
+func writeInfoModule(w io.Writer, kind string, m scan.Module) {
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", kind, m.Path, m.Version, m.Sum)
+	if m.Replace != nil {
+		writeInfoModule(w, "=>", *m.Replace)
+	}
+}
@@ -0,0 +1,81 @@
+package analysis
+
+import (
+	"fmt"
+
+	"golang.org/x/arch/x86/x86asm"
+
+	"github.com/nick-jones/gost/internal/arm64"
+	"github.com/nick-jones/gost/internal/exe"
+)
+
+// FindAddressReferences locates instructions that reference any of the supplied addresses, regardless of what
+// (if anything) they are paired with. This suits data that is not referenced as a string, such as a byte slice copied
+// from a constant. The returned map is keyed by the address referenced, with values being the reference addresses.
+func FindAddressReferences(f *exe.File, addrs []uint64) (map[uint64][]uint64, error) {
+	wanted := make(map[uint64]bool, len(addrs))
+	for _, addr := range addrs {
+		wanted[addr] = true
+	}
+
+	switch arch := f.Arch(); arch {
+	case exe.ArchAMD64:
+		return findAMD64AddressReferences(f, wanted)
+	case exe.ArchARM64:
+		return findARM64AddressReferences(f, wanted)
+	default:
+		return nil, fmt.Errorf("unsupported architecture: %s", arch)
+	}
+}
+
+// findAMD64AddressReferences decodes x86-64 instructions, looking for rip-relative operands of interest. Both address
+// calculation (`lea`) and memory operands (e.g. loading a slice header) are included.
+func findAMD64AddressReferences(f *exe.File, wanted map[uint64]bool) (map[uint64][]uint64, error) {
+	txt, data, err := textSectionData(f)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make(map[uint64][]uint64)
+	for i := 0; i < len(data); {
+		pc := txt.AddrRange.Start + uint64(i)
+		inst, err := x86asm.Decode(data[i:], 64)
+		if err != nil {
+			i++ // not a valid instruction; skip a byte and try to resynchronise
+			continue
+		}
+		for _, arg := range inst.Args {
+			mem, ok := arg.(x86asm.Mem)
+			if !ok || mem.Base != x86asm.RIP || mem.Index != 0 {
+				continue
+			}
+			if addr := uint64(int64(pc) + int64(inst.Len) + mem.Disp); wanted[addr] {
+				refs[addr] = append(refs[addr], pc)
+			}
+		}
+		i += inst.Len
+	}
+	return refs, nil
+}
+
+// findARM64AddressReferences searches AArch64 instructions for `adrp` + `add` materialisation of addresses of interest
+func findARM64AddressReferences(f *exe.File, wanted map[uint64]bool) (map[uint64][]uint64, error) {
+	txt, data, err := textSectionData(f)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		regs [32]trackedValue
+		refs = make(map[uint64][]uint64)
+	)
+	bo := f.ByteOrder()
+	for i := 0; i+arm64.InstructionSize <= len(data); i += arm64.InstructionSize {
+		index := i / arm64.InstructionSize
+		rd := trackARM64(&regs, bo.Uint32(data[i:]), txt.AddrRange.Start+uint64(i), index)
+		if rd >= 0 && regs[rd].kind == valueAddr && wanted[regs[rd].value] {
+			refs[regs[rd].value] = append(refs[regs[rd].value], regs[rd].refAddr)
+		}
+	}
+	return refs, nil
+}
@@ -0,0 +1,499 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/arch/x86/x86asm"
+
+	"github.com/nick-jones/gost/internal/address"
+	"github.com/nick-jones/gost/internal/exe"
+)
+
+// amd64ArgRegs are the integer registers used to pass arguments under Go's register ABI, in order. A string occupies
+// 2 consecutive registers; the pointer followed by the length.
+var amd64ArgRegs = []x86asm.Reg{
+	x86asm.RAX, x86asm.RBX, x86asm.RCX, x86asm.RDI, x86asm.RSI, x86asm.R8, x86asm.R9, x86asm.R10, x86asm.R11,
+}
+
+// amd64Window is the maximum number of instructions that may sit between the 2 halves of a pointer & length pair (or
+// type & value header pair) for them to be considered related. This is wider than arm64Window, since spills, reloads
+// and calls may sit between the halves.
+const amd64Window = 32
+
+// amd64OutgoingArgs is the size of the area at the bottom of a frame that a callee may write to: the spill slots of the
+// register arguments (or, under the stack ABI, the arguments & results themselves)
+var amd64OutgoingArgs = int64(8 * len(amd64ArgRegs))
+
+// amd64DWARFRegs maps general purpose registers to their DWARF register numbers
+var amd64DWARFRegs = map[x86asm.Reg]int{
+	x86asm.RAX: 0, x86asm.RDX: 1, x86asm.RCX: 2, x86asm.RBX: 3, x86asm.RSI: 4, x86asm.RDI: 5, x86asm.RBP: 6,
+	x86asm.RSP: 7, x86asm.R8: 8, x86asm.R9: 9, x86asm.R10: 10, x86asm.R11: 11, x86asm.R12: 12, x86asm.R13: 13,
+	x86asm.R14: 14, x86asm.R15: 15,
+}
+
+// amd64Loc is a location that can hold a value: either a register, or memory at an offset from a base register
+type amd64Loc struct {
+	reg  x86asm.Reg
+	mem  bool
+	disp int64
+}
+
+// next returns the location that the second word of a 2 word value (such as a string header) would be placed in,
+// given the location of the first word. Registers follow the register ABI ordering, memory is simply 8 bytes on.
+func (l amd64Loc) next() (amd64Loc, bool) {
+	if l.mem {
+		return amd64Loc{reg: l.reg, mem: true, disp: l.disp + 8}, true
+	}
+	for i, r := range amd64ArgRegs[:len(amd64ArgRegs)-1] {
+		if r == l.reg {
+			return amd64Loc{reg: amd64ArgRegs[i+1]}, true
+		}
+	}
+	return amd64Loc{}, false
+}
+
+// argSlot returns the position of the location within the register ABI argument registers
+func (l amd64Loc) argSlot() (int, bool) {
+	if l.mem {
+		return 0, false
+	}
+	for i, r := range amd64ArgRegs {
+		if r == l.reg {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// prev returns the location that the first word of a 2 word value would be placed in, given the location of the
+// second word. It is the inverse of next.
+func (l amd64Loc) prev() (amd64Loc, bool) {
+	if l.mem {
+		return amd64Loc{reg: l.reg, mem: true, disp: l.disp - 8}, true
+	}
+	for i, r := range amd64ArgRegs[1:] {
+		if r == l.reg {
+			return amd64Loc{reg: amd64ArgRegs[i]}, true
+		}
+	}
+	return amd64Loc{}, false
+}
+
+// amd64Tracker decodes x86-64 instructions and tracks the contents of registers and memory locations over the course
+// of each function. Only values of interest are tracked: addresses loaded via rip-relative lea, and immediates. These
+// are followed through register copies, spills to memory and reloads. Callers are notified of each location that is
+// set to such a value, which is where pairing rules are applied.
+type amd64Tracker struct {
+	funcStarts []uint64        // entry address of every function, in order
+	preserving map[uint64]bool // entry addresses of functions that preserve all registers
+	locs       map[amd64Loc]trackedValue
+	index      int
+	pc, next   uint64 // address of the instruction being applied, and of the one following it
+}
+
+// newAMD64Tracker initialises the amd64Tracker type. The supplied functions must be ordered by address.
+func newAMD64Tracker(funcs []exe.Symbol) *amd64Tracker {
+	t := &amd64Tracker{
+		funcStarts: make([]uint64, len(funcs)),
+		preserving: make(map[uint64]bool),
+		locs:       make(map[amd64Loc]trackedValue),
+	}
+	for i, fn := range funcs {
+		t.funcStarts[i] = fn.AddrRange.Start
+		// write barriers are called between setting a struct field's length & pointer, but preserve all registers
+		if strings.HasPrefix(fn.Name, "runtime.gcWriteBarrier") {
+			t.preserving[fn.AddrRange.Start] = true
+		}
+	}
+	return t
+}
+
+// walk decodes every instruction in the supplied data, invoking fn whenever a location is set to a tracked value.
+// Everything known is forgotten at the start of each function.
+func (t *amd64Tracker) walk(data []byte, base uint64, fn func(loc amd64Loc, val trackedValue)) {
+	next := 0
+	for i := 0; i < len(data); {
+		pc := base + uint64(i)
+		if next < len(t.funcStarts) && pc >= t.funcStarts[next] {
+			t.reset()
+			for next < len(t.funcStarts) && pc >= t.funcStarts[next] {
+				next++
+			}
+		}
+
+		inst, err := x86asm.Decode(data[i:], 64)
+		if err != nil {
+			// not a valid instruction (e.g. data embedded in text); skip a byte and try to resynchronise
+			t.reset()
+			i++
+			continue
+		}
+		t.pc, t.next = pc, pc+uint64(inst.Len)
+		if loc, ok := t.step(inst, pc); ok {
+			fn(loc, t.locs[loc])
+		}
+		t.index++
+		i += inst.Len
+	}
+}
+
+// get returns the value held in a location, if any
+func (t *amd64Tracker) get(loc amd64Loc) trackedValue {
+	return t.locs[loc]
+}
+
+// step applies the effects of a single instruction. If a location was set to a tracked value, it is returned.
+func (t *amd64Tracker) step(inst x86asm.Inst, pc uint64) (amd64Loc, bool) {
+	switch inst.Op {
+	case x86asm.CALL:
+		t.call(inst, pc)
+		return amd64Loc{}, false
+	case x86asm.JMP, x86asm.RET, x86asm.INT:
+		// code that follows is reached from elsewhere (or is padding between functions), so nothing carries over
+		t.reset()
+		return amd64Loc{}, false
+	case x86asm.LEA:
+		return t.lea(inst, pc)
+	case x86asm.MOV:
+		return t.mov(inst, pc)
+	default:
+		t.invalidate(inst)
+		return amd64Loc{}, false
+	}
+}
+
+// call forgets anything that cannot be relied upon across a call. Registers are not preserved (nor is memory addressed
+// by them), but stack slots in the caller's frame are, other than the outgoing argument area that the callee writes to.
+func (t *amd64Tracker) call(inst x86asm.Inst, pc uint64) {
+	if rel, ok := inst.Args[0].(x86asm.Rel); ok && t.preserving[uint64(int64(pc)+int64(inst.Len)+int64(rel))] {
+		return
+	}
+	for loc := range t.locs {
+		switch {
+		case !loc.mem:
+		case loc.reg == x86asm.RBP:
+			continue
+		case loc.reg == x86asm.RSP && loc.disp >= amd64OutgoingArgs:
+			continue
+		}
+		delete(t.locs, loc)
+	}
+}
+
+// lea applies an address calculation, which is only of interest when rip-relative. Whatever the destination register
+// previously pointed at is forgotten, since it now points elsewhere.
+func (t *amd64Tracker) lea(inst x86asm.Inst, pc uint64) (amd64Loc, bool) {
+	dst, ok := amd64Location(inst.Args[0])
+	if !ok {
+		return amd64Loc{}, false
+	}
+	t.clobber(dst, 0)
+	mem, ok := inst.Args[1].(x86asm.Mem)
+	if !ok || mem.Base != x86asm.RIP || mem.Index != 0 {
+		return amd64Loc{}, false
+	}
+	addr := uint64(int64(pc) + int64(inst.Len) + mem.Disp)
+	t.locs[dst] = trackedValue{kind: valueAddr, value: addr, refAddr: pc, index: t.index}
+	return dst, true
+}
+
+// mov applies a move of an immediate, register or memory location. Whatever is known about the source is copied.
+func (t *amd64Tracker) mov(inst x86asm.Inst, pc uint64) (amd64Loc, bool) {
+	dst, ok := amd64Location(inst.Args[0])
+	if !ok {
+		t.invalidate(inst)
+		return amd64Loc{}, false
+	}
+	t.clobber(dst, inst.MemBytes)
+	if inst.DataSize < 32 {
+		return amd64Loc{}, false // partial writes can't hold anything of interest
+	}
+	var val trackedValue
+	switch src := inst.Args[1].(type) {
+	case x86asm.Imm:
+		val = trackedValue{kind: valueImm, value: uint64(src), refAddr: pc, index: t.index}
+	case x86asm.Reg, x86asm.Mem:
+		// copy whatever is known about the source; this covers register moves, spills and reloads
+		if loc, ok := amd64Location(src); ok {
+			val = t.locs[loc]
+		}
+	}
+	if val.kind == valueNone {
+		return amd64Loc{}, false
+	}
+	t.locs[dst] = val
+	return dst, true
+}
+
+// invalidate forgets anything known about locations written by an instruction that is not otherwise understood
+func (t *amd64Tracker) invalidate(inst x86asm.Inst) {
+	switch inst.Op {
+	case x86asm.PUSH, x86asm.POP, x86asm.PUSHF, x86asm.POPF:
+		// these move the stack pointer, so stack slots addressed by it are now elsewhere
+		t.clobber(amd64Loc{reg: x86asm.RSP}, 0)
+		if inst.Op != x86asm.POP {
+			return // nor do these write to a general purpose register
+		}
+	case x86asm.CMP, x86asm.TEST, x86asm.BT, x86asm.NOP, x86asm.PREFETCHT0, x86asm.UCOMISD, x86asm.UCOMISS:
+		return // these do not write to their first argument
+	case x86asm.MUL, x86asm.IMUL, x86asm.DIV, x86asm.IDIV, x86asm.CQO, x86asm.CDQ, x86asm.CPUID, x86asm.RDTSC:
+		// these implicitly write to rax & rdx
+		t.clobber(amd64Loc{reg: x86asm.RAX}, 0)
+		t.clobber(amd64Loc{reg: x86asm.RDX}, 0)
+	case x86asm.MOVSB, x86asm.MOVSQ, x86asm.STOSB, x86asm.STOSQ:
+		// string operations (typically with a rep prefix) advance rdi & rsi and count down rcx
+		t.clobber(amd64Loc{reg: x86asm.RDI}, 0)
+		t.clobber(amd64Loc{reg: x86asm.RSI}, 0)
+		t.clobber(amd64Loc{reg: x86asm.RCX}, 0)
+	case x86asm.XCHG, x86asm.XADD, x86asm.CMPXCHG:
+		// these write to both arguments (and cmpxchg to rax)
+		if loc, ok := amd64Location(inst.Args[1]); ok {
+			t.clobber(loc, inst.MemBytes)
+		}
+		t.clobber(amd64Loc{reg: x86asm.RAX}, 0)
+	}
+	if dst, ok := amd64Location(inst.Args[0]); ok {
+		t.clobber(dst, inst.MemBytes)
+	}
+}
+
+// clobber forgets anything known about a location. For memory, every word overlapping the supplied size is forgotten.
+// For registers, memory addressed by that register is also forgotten, since it now points elsewhere.
+func (t *amd64Tracker) clobber(loc amd64Loc, size int) {
+	delete(t.locs, loc)
+	if !loc.mem {
+		for other := range t.locs {
+			if other.mem && other.reg == loc.reg {
+				delete(t.locs, other)
+			}
+		}
+		return
+	}
+	for off := int64(8); off < int64(size); off += 8 {
+		delete(t.locs, amd64Loc{reg: loc.reg, mem: true, disp: loc.disp + off})
+	}
+}
+
+// reset forgets everything that is known
+func (t *amd64Tracker) reset() {
+	for loc := range t.locs {
+		delete(t.locs, loc)
+	}
+}
+
+// amd64Location maps an instruction argument to a location. Registers are widened to 64-bit, since writes to 32-bit
+// registers zero the upper half (and the Go compiler uses these to load small constants). Memory is only supported
+// when addressed by base register & displacement.
+func amd64Location(arg x86asm.Arg) (amd64Loc, bool) {
+	switch a := arg.(type) {
+	case x86asm.Reg:
+		if r := amd64Reg64(a); r != 0 {
+			return amd64Loc{reg: r}, true
+		}
+	case x86asm.Mem:
+		if a.Base != 0 && a.Base != x86asm.RIP && a.Index == 0 && a.Segment == 0 {
+			return amd64Loc{reg: amd64Reg64(a.Base), mem: true, disp: a.Disp}, true
+		}
+	}
+	return amd64Loc{}, false
+}
+
+// amd64Reg64 returns the 64-bit general purpose register that contains the supplied register. Zero is returned for
+// anything that is not a general purpose register.
+func amd64Reg64(r x86asm.Reg) x86asm.Reg {
+	switch {
+	case r >= x86asm.RAX && r <= x86asm.R15:
+		return r
+	case r >= x86asm.EAX && r <= x86asm.R15L:
+		return r - x86asm.EAX + x86asm.RAX
+	case r >= x86asm.AX && r <= x86asm.R15W:
+		return r - x86asm.AX + x86asm.RAX
+	case r >= x86asm.AH && r <= x86asm.BH:
+		return r - x86asm.AH + x86asm.RAX
+	case r >= x86asm.AL && r <= x86asm.BL:
+		return r - x86asm.AL + x86asm.RAX
+	case r >= x86asm.SPB && r <= x86asm.DIB:
+		return r - x86asm.SPB + x86asm.RSP
+	case r >= x86asm.R8B && r <= x86asm.R15B:
+		return r - x86asm.R8B + x86asm.R8
+	default:
+		return 0
+	}
+}
+
+// evaluateAMD64DirectReferences decodes x86-64 instructions, pairing rip-relative addresses with immediate lengths.
+// Two rules cover the ways Go passes strings around: the pointer & length in consecutive register ABI registers
+// (function arguments), or in adjacent words of memory (stack arguments under the old ABI, struct fields, slices).
+// Since values are tracked across each function, the pointer and length need not be set by adjacent instructions, but
+// must be set within amd64Window instructions of each other.
+func evaluateAMD64DirectReferences(f *exe.File, strRange *address.Range) ([]Candidate, error) {
+	txt, data, err := textSectionData(f)
+	if err != nil {
+		return nil, err
+	}
+
+	funcs, err := f.Functions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read functions: %w", err)
+	}
+
+	var (
+		tracker    = newAMD64Tracker(funcs)
+		emitted    = make(map[uint64]bool)
+		candidates []Candidate
+	)
+	tracker.walk(data, txt.AddrRange.Start, func(loc amd64Loc, val trackedValue) {
+		var (
+			ptr, length trackedValue
+			ptrLoc      = loc
+		)
+		switch val.kind {
+		case valueAddr:
+			next, ok := loc.next()
+			if !ok {
+				return
+			}
+			ptr, length = val, tracker.get(next)
+		case valueImm:
+			prev, ok := loc.prev()
+			if !ok {
+				return
+			}
+			ptr, length, ptrLoc = tracker.get(prev), val, prev
+		}
+		if !ptr.available(valueAddr, tracker.index, amd64Window) || !length.available(valueImm, tracker.index, amd64Window) {
+			return
+		}
+		if emitted[ptr.refAddr] || strRange != nil && !strRange.Contains(ptr.value) {
+			return
+		}
+		emitted[ptr.refAddr] = true
+		candidate := Candidate{
+			Addr:     ptr.value,
+			Len:      length.value,
+			RefAddrs: []uint64{ptr.refAddr},
+		}
+		if slot, ok := ptrLoc.argSlot(); ok {
+			candidate.ArgSlots = map[uint64]int{ptr.refAddr: slot}
+		}
+		if ptrLoc.mem && ptrLoc.reg != x86asm.RSP && ptrLoc.reg != x86asm.RBP {
+			// not a local, so a heap object
+			candidate.FieldOffsets = map[uint64]int64{ptr.refAddr: ptrLoc.disp}
+		}
+		if reg, ok := amd64DWARFRegs[ptrLoc.reg]; ok {
+			candidate.Destinations = map[uint64]Destination{ptr.refAddr: {
+				Reg:    reg,
+				Mem:    ptrLoc.mem,
+				Offset: ptrLoc.disp,
+				PC:     tracker.pc,
+				Next:   tracker.next,
+			}}
+		}
+		candidates = append(candidates, candidate)
+	})
+	return candidates, nil
+}
+
+// findAMD64InterfaceReferences decodes x86-64 instructions, looking for a pair of rip-relative addresses placed in
+// related locations (by the same rules as evaluateAMD64DirectReferences): the type followed by the value header.
+func findAMD64InterfaceReferences(f *exe.File) ([]interfaceReference, error) {
+	txt, data, err := textSectionData(f)
+	if err != nil {
+		return nil, err
+	}
+
+	funcs, err := f.Functions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read functions: %w", err)
+	}
+
+	var (
+		tracker    = newAMD64Tracker(funcs)
+		emitted    = make(map[uint64]bool)
+		references []interfaceReference
+	)
+	tracker.walk(data, txt.AddrRange.Start, func(loc amd64Loc, val trackedValue) {
+		if val.kind != valueAddr {
+			return
+		}
+		// the pair may be populated in either order, so check both sides
+		typ, header := trackedValue{}, trackedValue{}
+		if prev, ok := loc.prev(); ok && tracker.get(prev).available(valueAddr, tracker.index, amd64Window) {
+			typ, header = tracker.get(prev), val
+		} else if next, ok := loc.next(); ok && tracker.get(next).available(valueAddr, tracker.index, amd64Window) {
+			typ, header = val, tracker.get(next)
+		} else {
+			return
+		}
+		if emitted[header.refAddr] {
+			return
+		}
+		emitted[header.refAddr] = true
+		references = append(references, interfaceReference{
+			addr:            header.refAddr,
+			typeAddr:        typ.value,
+			valueHeaderAddr: header.value,
+		})
+	})
+	return references, nil
+}
+
+// findAMD64Comparisons decodes x86-64 instructions, looking for strings compared against constants: a length check of
+// a register, followed by comparisons of memory addressed by the string pointer against immediates. Immediates wider
+// than 32 bits cannot be compared directly, so are loaded into a register by the preceding instruction.
+func findAMD64Comparisons(f *exe.File) ([]comparison, error) {
+	txt, data, err := textSectionData(f)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		pending     *pendingComparison
+		wide        amd64Loc // register loaded with a 64-bit immediate by the previous instruction
+		wideImm     uint64
+		comparisons []comparison
+	)
+	for i, index := 0, 0; i < len(data); index++ {
+		pc := txt.AddrRange.Start + uint64(i)
+		inst, err := x86asm.Decode(data[i:], 64)
+		if err != nil {
+			i++ // not a valid instruction; skip a byte and try to resynchronise
+			continue
+		}
+		i += inst.Len
+
+		prevWide := wide
+		wide = amd64Loc{}
+		if inst.Op == x86asm.MOV && inst.DataSize == 64 {
+			dst, isLoc := amd64Location(inst.Args[0])
+			if imm, ok := inst.Args[1].(x86asm.Imm); ok && isLoc && !dst.mem {
+				wide, wideImm = dst, uint64(imm)
+			}
+			continue
+		}
+		if inst.Op != x86asm.CMP {
+			continue
+		}
+		if reg, ok := inst.Args[0].(x86asm.Reg); ok && reg >= x86asm.RAX && reg <= x86asm.R15 {
+			if imm, ok := inst.Args[1].(x86asm.Imm); ok {
+				pending = newPendingComparison(uint64(imm), pc, index)
+			}
+			continue
+		}
+		dst, ok := amd64Location(inst.Args[0])
+		if !ok || !dst.mem || pending == nil {
+			continue
+		}
+		imm, ok := inst.Args[1].(x86asm.Imm)
+		if src, isLoc := amd64Location(inst.Args[1]); isLoc && src == prevWide && inst.MemBytes == 8 {
+			imm, ok = x86asm.Imm(wideImm), true
+		}
+		if ok && pending.fits(int(dst.reg), dst.disp, inst.MemBytes, index) &&
+			pending.add(int(dst.reg), dst.disp, inst.MemBytes, uint64(imm), f.ByteOrder()) {
+			comparisons = append(comparisons, comparison{value: string(pending.value), refAddr: pending.refAddr})
+			pending = nil
+		}
+	}
+	return comparisons, nil
+}
@@ -0,0 +1,135 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+func TestAMD64Tracker_Walk(t *testing.T) {
+	data := []byte{
+		0x48, 0x8d, 0x05, 0x75, 0x13, 0x00, 0x00, // lea rax, [rip + 0x1375]
+		0xbb, 0x06, 0x00, 0x00, 0x00, // mov ebx, 6
+		0x48, 0xc7, 0x40, 0x08, 0x06, 0x00, 0x00, 0x00, // mov qword ptr [rax + 8], 6
+		0x48, 0x8d, 0x15, 0xb4, 0x13, 0x00, 0x00, // lea rdx, [rip + 0x13b4]
+		0x48, 0x89, 0x10, // mov qword ptr [rax], rdx
+	}
+
+	type set struct {
+		loc amd64Loc
+		val trackedValue
+	}
+	var actual []set
+	newAMD64Tracker(nil).walk(data, 0x1000, func(loc amd64Loc, val trackedValue) {
+		actual = append(actual, set{loc: loc, val: val})
+	})
+
+	rax, rbx, rdx := x86asm.RAX, x86asm.RBX, x86asm.RDX
+	expected := []set{
+		{
+			loc: amd64Loc{reg: rax},
+			val: trackedValue{kind: valueAddr, value: 0x1007 + 0x1375, refAddr: 0x1000, index: 0},
+		},
+		{
+			loc: amd64Loc{reg: rbx},
+			val: trackedValue{kind: valueImm, value: 6, refAddr: 0x1007, index: 1},
+		},
+		{
+			loc: amd64Loc{reg: rax, mem: true, disp: 8},
+			val: trackedValue{kind: valueImm, value: 6, refAddr: 0x100c, index: 2},
+		},
+		{
+			loc: amd64Loc{reg: rdx},
+			val: trackedValue{kind: valueAddr, value: 0x101b + 0x13b4, refAddr: 0x1014, index: 3},
+		},
+		{
+			// the address is copied into memory, adjacent to the length
+			loc: amd64Loc{reg: rax, mem: true, disp: 0},
+			val: trackedValue{kind: valueAddr, value: 0x101b + 0x13b4, refAddr: 0x1014, index: 3},
+		},
+	}
+	assert.Equal(t, expected, actual)
+}
+
+func TestAMD64Tracker_Walk_Call(t *testing.T) {
+	data := []byte{
+		0x48, 0xc7, 0x44, 0x24, 0x08, 0x06, 0x00, 0x00, 0x00, // mov qword ptr [rsp + 8], 6
+		0x48, 0xc7, 0x44, 0x24, 0x50, 0x06, 0x00, 0x00, 0x00, // mov qword ptr [rsp + 0x50], 6
+		0xbb, 0x06, 0x00, 0x00, 0x00, // mov ebx, 6
+		0xe8, 0x00, 0x00, 0x00, 0x00, // call 0x101d
+	}
+
+	tracker := newAMD64Tracker(nil)
+	tracker.walk(data, 0x1000, func(amd64Loc, trackedValue) {})
+
+	// the callee may write to the outgoing argument area, and registers, but not the rest of the frame
+	assert.Equal(t, trackedValue{}, tracker.get(amd64Loc{reg: x86asm.RSP, mem: true, disp: 8}))
+	assert.Equal(t, trackedValue{}, tracker.get(amd64Loc{reg: x86asm.RBX}))
+	assert.Equal(t, valueImm, tracker.get(amd64Loc{reg: x86asm.RSP, mem: true, disp: 0x50}).kind)
+}
+
+func TestAMD64Tracker_Walk_LEA(t *testing.T) {
+	data := []byte{
+		0x48, 0xc7, 0x40, 0x08, 0x06, 0x00, 0x00, 0x00, // mov qword ptr [rax + 8], 6
+		0x48, 0x8d, 0x44, 0x24, 0x10, // lea rax, [rsp + 0x10]
+	}
+
+	tracker := newAMD64Tracker(nil)
+	tracker.walk(data, 0x1000, func(amd64Loc, trackedValue) {})
+
+	// rax now points elsewhere, so memory addressed by it is forgotten
+	assert.Equal(t, trackedValue{}, tracker.get(amd64Loc{reg: x86asm.RAX, mem: true, disp: 8}))
+}
+
+func TestAMD64Tracker_Walk_Push(t *testing.T) {
+	data := []byte{
+		0x48, 0xc7, 0x44, 0x24, 0x50, 0x06, 0x00, 0x00, 0x00, // mov qword ptr [rsp + 0x50], 6
+		0x55, // push rbp
+	}
+
+	tracker := newAMD64Tracker(nil)
+	tracker.walk(data, 0x1000, func(amd64Loc, trackedValue) {})
+
+	// the stack pointer has moved, so the slot is no longer at the same offset
+	assert.Equal(t, trackedValue{}, tracker.get(amd64Loc{reg: x86asm.RSP, mem: true, disp: 0x50}))
+}
+
+func TestAMD64Loc_Next(t *testing.T) {
+	testCases := []struct {
+		name   string
+		loc    amd64Loc
+		expect amd64Loc
+		ok     bool
+	}{
+		{
+			name:   "register ABI",
+			loc:    amd64Loc{reg: amd64ArgRegs[2]},
+			expect: amd64Loc{reg: amd64ArgRegs[3]},
+			ok:     true,
+		},
+		{
+			name: "last register ABI register",
+			loc:  amd64Loc{reg: amd64ArgRegs[len(amd64ArgRegs)-1]},
+			ok:   false,
+		},
+		{
+			name:   "memory",
+			loc:    amd64Loc{reg: x86asm.RAX, mem: true, disp: 0x30},
+			expect: amd64Loc{reg: x86asm.RAX, mem: true, disp: 0x38},
+			ok:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			next, ok := tc.loc.next()
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.expect, next)
+			if ok {
+				prev, _ := next.prev()
+				assert.Equal(t, tc.loc, prev)
+			}
+		})
+	}
+}
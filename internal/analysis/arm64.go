@@ -0,0 +1,347 @@
+package analysis
+
+import (
+	"fmt"
+
+	"github.com/nick-jones/gost/internal/address"
+	"github.com/nick-jones/gost/internal/arm64"
+	"github.com/nick-jones/gost/internal/exe"
+)
+
+// arm64Window is the maximum number of instructions that may sit between the 2 halves of a pointer & length pair (or
+// type & value header pair) for them to be considered related.
+const arm64Window = 8
+
+// arm64ArgRegs is the number of integer registers (R0 upwards) used to pass arguments under Go's register ABI
+const arm64ArgRegs = 16
+
+// evaluateARM64DirectReferences searches AArch64 instructions for direct references. Unlike x86-64, addresses cannot
+// be expressed in a single instruction, so strings are located by tracking `adrp` + `add` address materialisation
+// and pairing the address with a constant loaded via `movz` or `orr`. Go's register ABI places the length in the
+// register following the pointer; struct fields & stack slots are instead populated by `stp` of the 2 registers.
+func evaluateARM64DirectReferences(f *exe.File, strRange *address.Range) ([]Candidate, error) {
+	txt, data, err := textSectionData(f)
+	if err != nil {
+		return nil, err
+	}
+
+	p := newARM64Pairer(strRange)
+	bo := f.ByteOrder()
+	for i := 0; i+arm64.InstructionSize <= len(data); i += arm64.InstructionSize {
+		insn := bo.Uint32(data[i:])
+		index := i / arm64.InstructionSize
+		p.release(index, arm64.IsBranch(insn))
+
+		pc := txt.AddrRange.Start + uint64(i)
+		if !p.store(insn, pc, index) {
+			p.step(insn, pc, index)
+		}
+	}
+	p.release(len(data)/arm64.InstructionSize, true)
+	return p.candidates, nil
+}
+
+// heldPair is a pointer & length pair awaiting confirmation that the length belongs with the pointer
+type heldPair struct {
+	ptrReg      int
+	ptr, length trackedValue
+	pc          uint64
+}
+
+// arm64Pairer tracks register contents over AArch64 instructions, pairing pointers with lengths to form candidates.
+// A pointer materialised while the following register already holds a constant is held back, since the constant is
+// commonly left over from an earlier statement (e.g. a map value), with the length following the pointer. The earlier
+// constant is only paired if neither register changes before a branch, or before the window passes.
+type arm64Pairer struct {
+	regs       [32]trackedValue
+	strRange   *address.Range
+	emitted    map[uint64]int // reference address to candidate index
+	candidates []Candidate
+	held       []heldPair
+}
+
+// newARM64Pairer initialises the arm64Pairer type. Candidates are restricted to the string table, if supplied.
+func newARM64Pairer(strRange *address.Range) *arm64Pairer {
+	return &arm64Pairer{strRange: strRange, emitted: make(map[uint64]int)}
+}
+
+// emit records a candidate, returning it so that details of its placement can be added. If a candidate was already
+// recorded for the reference, that candidate is returned instead. Nil is returned if out of range.
+func (p *arm64Pairer) emit(ptrReg, lenReg int) *Candidate {
+	ptr, length := p.regs[ptrReg], p.regs[lenReg]
+	if p.strRange != nil && !p.strRange.Contains(ptr.value) {
+		return nil
+	}
+	if i, found := p.emitted[ptr.refAddr]; found {
+		return &p.candidates[i]
+	}
+	p.emitted[ptr.refAddr] = len(p.candidates)
+	p.candidates = append(p.candidates, Candidate{
+		Addr:     ptr.value,
+		Len:      length.value,
+		RefAddrs: []uint64{ptr.refAddr},
+	})
+	return &p.candidates[len(p.candidates)-1]
+}
+
+// release emits held pairs whose registers are unchanged at a branch, or once the window has passed. Pairs whose
+// registers have changed are dropped: a length set since is paired as it is set, anything else leaves them unrelated.
+func (p *arm64Pairer) release(index int, branch bool) {
+	kept := p.held[:0]
+	for _, h := range p.held {
+		switch {
+		case p.regs[h.ptrReg] != h.ptr || p.regs[h.ptrReg+1] != h.length:
+		case branch || index-h.ptr.index > arm64Window:
+			emitArg(p.emit(h.ptrReg, h.ptrReg+1), h.ptrReg, h.pc)
+		default:
+			kept = append(kept, h)
+		}
+	}
+	p.held = kept
+}
+
+// store handles `stp` of a pointer & length into memory, returning false if the instruction is not a store pair
+func (p *arm64Pairer) store(insn uint32, pc uint64, index int) bool {
+	rt, rt2, rn, offset, ok := arm64.DecodeSTP(insn)
+	if !ok {
+		return false
+	}
+	if !p.regs[rt].available(valueAddr, index, arm64Window) || !p.regs[rt2].available(valueImm, index, arm64Window) {
+		return true
+	}
+	c := p.emit(rt, rt2)
+	if c == nil {
+		return true
+	}
+	// the pair may have been mistaken for arguments when loaded, but is stored rather than passed. Stores relative to
+	// the stack or frame pointer are locals, anything else is a heap object.
+	c.ArgSlots = nil
+	if rn != arm64.SP && rn != arm64.FP {
+		c.FieldOffsets = map[uint64]int64{c.RefAddrs[0]: offset}
+	}
+	c.Destinations = map[uint64]Destination{c.RefAddrs[0]: {
+		Reg:    rn,
+		Mem:    true,
+		Offset: offset,
+		PC:     pc,
+		Next:   pc + arm64.InstructionSize,
+	}}
+	return true
+}
+
+// step applies any other instruction, pairing a pointer & length passed in consecutive registers
+func (p *arm64Pairer) step(insn uint32, pc uint64, index int) {
+	rd := trackARM64(&p.regs, insn, pc, index)
+	switch {
+	case rd < 0:
+	case p.regs[rd].kind == valueAddr && rd+1 < arm64.ZR && p.regs[rd+1].available(valueImm, index, arm64Window):
+		p.held = append(p.held, heldPair{ptrReg: rd, ptr: p.regs[rd], length: p.regs[rd+1], pc: pc})
+	case p.regs[rd].kind == valueImm && rd > 0 && p.regs[rd-1].available(valueAddr, index, arm64Window):
+		emitArg(p.emit(rd-1, rd), rd-1, pc)
+	}
+}
+
+// emitArg records the argument slot of a candidate passed in registers, if it falls within the argument registers,
+// along with the register as its destination. Candidates whose placement is already known are left alone.
+func emitArg(c *Candidate, ptrReg int, pc uint64) {
+	if c == nil || c.ArgSlots != nil || c.FieldOffsets != nil || c.Destinations != nil {
+		return
+	}
+	if ptrReg < arm64ArgRegs {
+		c.ArgSlots = map[uint64]int{c.RefAddrs[0]: ptrReg}
+	}
+	// registers share their numbering with DWARF
+	c.Destinations = map[uint64]Destination{c.RefAddrs[0]: {Reg: ptrReg, PC: pc, Next: pc + arm64.InstructionSize}}
+}
+
+// findARM64InterfaceReferences searches AArch64 instructions for a pair of materialised addresses, where the first is
+// the type and the second is the value header. These are either passed in adjacent registers, or stored as a pair.
+func findARM64InterfaceReferences(f *exe.File) ([]interfaceReference, error) {
+	txt, data, err := textSectionData(f)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		regs       [32]trackedValue
+		emitted    = make(map[uint64]bool)
+		references []interfaceReference
+	)
+	emit := func(typeReg, valueReg int) {
+		if emitted[regs[valueReg].refAddr] {
+			return
+		}
+		emitted[regs[valueReg].refAddr] = true
+		references = append(references, interfaceReference{
+			addr:            regs[valueReg].refAddr,
+			typeAddr:        regs[typeReg].value,
+			valueHeaderAddr: regs[valueReg].value,
+		})
+	}
+
+	bo := f.ByteOrder()
+	for i := 0; i+arm64.InstructionSize <= len(data); i += arm64.InstructionSize {
+		insn := bo.Uint32(data[i:])
+		index := i / arm64.InstructionSize
+
+		if rt, rt2, _, _, ok := arm64.DecodeSTP(insn); ok {
+			if regs[rt].available(valueAddr, index, arm64Window) && regs[rt2].available(valueAddr, index, arm64Window) {
+				emit(rt, rt2)
+			}
+			continue
+		}
+
+		rd := trackARM64(&regs, insn, txt.AddrRange.Start+uint64(i), index)
+		if rd > 0 && regs[rd].kind == valueAddr && regs[rd-1].available(valueAddr, index, arm64Window) {
+			emit(rd-1, rd)
+		}
+	}
+	return references, nil
+}
+
+// arm64Load is the memory a register was loaded from, where the register may hold bytes of a string being compared
+type arm64Load struct {
+	base   int
+	offset int64
+	size   int // size in bytes of the value loaded, or 0 if the register was not loaded from memory
+}
+
+// findARM64Comparisons searches AArch64 instructions for strings compared against constants: a length check of a
+// register, followed by loads from memory addressed by the string pointer that are compared against immediates. Small
+// immediates are encoded within the comparison, while others are built in a register beforehand.
+func findARM64Comparisons(f *exe.File) ([]comparison, error) {
+	txt, data, err := textSectionData(f)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		regs        [32]trackedValue
+		loads       [32]arm64Load
+		pending     *pendingComparison
+		comparisons []comparison
+	)
+	bo := f.ByteOrder()
+	for i := 0; i+arm64.InstructionSize <= len(data); i += arm64.InstructionSize {
+		insn := bo.Uint32(data[i:])
+		index := i / arm64.InstructionSize
+		pc := txt.AddrRange.Start + uint64(i)
+
+		var (
+			load arm64Load
+			imm  uint64
+		)
+		if rn, cmpImm, ok := arm64.DecodeCMPImmediate(insn); ok {
+			// a length may itself have been loaded (e.g. reloaded from the stack), so is only a piece if it fits
+			if l := loads[rn]; l.size > 0 && pending != nil && pending.fits(l.base, l.offset, l.size, index) {
+				load, imm = l, cmpImm
+			} else {
+				pending = newPendingComparison(cmpImm, pc, index)
+			}
+		} else if rn, rm, ok := arm64.DecodeCMPRegister(insn); ok {
+			if loads[rn].size == 0 {
+				rn, rm = rm, rn // the operands may be either way around
+			}
+			if regs[rm].kind == valueImm {
+				load, imm = loads[rn], regs[rm].value
+			}
+		}
+		if load.size > 0 && pending != nil && pending.fits(load.base, load.offset, load.size, index) &&
+			pending.add(load.base, load.offset, load.size, imm, bo) {
+			comparisons = append(comparisons, comparison{value: string(pending.value), refAddr: pending.refAddr})
+			pending = nil
+		}
+
+		trackARM64Loads(&loads, insn)
+		trackARM64(&regs, insn, pc, index)
+	}
+	return comparisons, nil
+}
+
+// trackARM64Loads records the memory that registers are loaded from, forgetting registers that are otherwise written
+func trackARM64Loads(loads *[32]arm64Load, insn uint32) {
+	if arm64.IsBranch(insn) {
+		// as with trackARM64, registers cannot be relied upon across calls & jumps
+		*loads = [32]arm64Load{}
+		return
+	}
+	if rd, rd2, ok := arm64.DecodeWrittenRegisters(insn); ok {
+		loads[rd] = arm64Load{}
+		if rd2 >= 0 {
+			loads[rd2] = arm64Load{}
+		}
+	}
+	if rt, rn, offset, size, ok := arm64.DecodeLDR(insn); ok {
+		loads[rt] = arm64Load{base: rn, offset: offset, size: size}
+	} else if rt, rt2, rn, offset, ok := arm64.DecodeLDP(insn); ok {
+		loads[rt] = arm64Load{base: rn, offset: offset, size: 8}
+		loads[rt2] = arm64Load{base: rn, offset: offset + 8, size: 8}
+	}
+}
+
+// trackARM64 updates the known register contents based on the supplied instruction. The register that was set is
+// returned, or -1 if no register of interest was set.
+func trackARM64(regs *[32]trackedValue, insn uint32, pc uint64, index int) int {
+	if rd, addr, ok := arm64.DecodeADRP(insn, pc); ok {
+		regs[rd] = trackedValue{kind: valuePage, value: addr, refAddr: pc, index: index}
+		return rd
+	}
+	if rd, rn, imm, ok := arm64.DecodeADDImmediate(insn); ok {
+		if rd == arm64.ZR {
+			return -1
+		}
+		if regs[rn].kind != valuePage {
+			regs[rd] = trackedValue{}
+			return -1
+		}
+		regs[rd] = trackedValue{kind: valueAddr, value: regs[rn].value + imm, refAddr: regs[rn].refAddr, index: index}
+		return rd
+	}
+	if rd, imm, ok := arm64.DecodeMOVImmediate(insn); ok {
+		if rd == arm64.ZR {
+			return -1
+		}
+		regs[rd] = trackedValue{kind: valueImm, value: imm, refAddr: pc, index: index}
+		return rd
+	}
+	if rd, imm, shift, ok := arm64.DecodeMOVK(insn); ok && rd != arm64.ZR {
+		// wider constants are built up 16 bits at a time. The register was already reported when set by movz, so
+		// this is not reported again.
+		if regs[rd].kind != valueImm {
+			regs[rd] = trackedValue{}
+			return -1
+		}
+		regs[rd].value = regs[rd].value&^(0xffff<<shift) | imm<<shift
+		regs[rd].index = index
+		return -1
+	}
+	if arm64.IsBranch(insn) {
+		// registers cannot be relied upon across calls & jumps
+		*regs = [32]trackedValue{}
+		return -1
+	}
+	if rd, rd2, ok := arm64.DecodeWrittenRegisters(insn); ok {
+		// anything else written (e.g. a load, or a register move) leaves the register holding an unknown value
+		regs[rd] = trackedValue{}
+		if rd2 >= 0 {
+			regs[rd2] = trackedValue{}
+		}
+	}
+	return -1
+}
+
+// textSectionData returns the text section along with its data
+func textSectionData(f *exe.File) (exe.Section, []byte, error) {
+	// the __text section contains executable instructions
+	txt, err := f.TextSection()
+	if err != nil {
+		return exe.Section{}, nil, fmt.Errorf("failed to retrieve text section: %w", err)
+	}
+
+	// read data for this section
+	data, err := txt.Data()
+	if err != nil {
+		return exe.Section{}, nil, fmt.Errorf("could not read data from text section: %w", err)
+	}
+	return txt, data, nil
+}
@@ -0,0 +1,121 @@
+package analysis
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/arch/x86/x86asm"
+
+	"github.com/nick-jones/gost/internal/arm64"
+	"github.com/nick-jones/gost/internal/exe"
+)
+
+// calleeWindow is the maximum number of instructions searched following a reference for the call it is an argument to
+const calleeWindow = 32
+
+// Call is a direct call instruction
+type Call struct {
+	Addr   uint64 // address of the call instruction
+	Target uint64 // address of the function called
+}
+
+// FindCallees searches forward from each reference for the call that follows it, returning the call keyed by
+// reference address. This is a heuristic: a string is assumed to be an argument to the next direct
+// call, provided no unconditional branch or return is seen first. References without a plausible call are omitted.
+func FindCallees(f *exe.File, refAddrs []uint64) (map[uint64]Call, error) {
+	txt, data, err := textSectionData(f)
+	if err != nil {
+		return nil, err
+	}
+
+	funcs, err := f.Functions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read functions: %w", err)
+	}
+	skip := make(map[uint64]bool)
+	for _, fn := range funcs {
+		// write barriers are called while storing pointers, so are never the function a string is intended for.
+		// Concatenation passes its result on to the following call, which is the more useful answer.
+		if strings.HasPrefix(fn.Name, "runtime.gcWriteBarrier") || strings.HasPrefix(fn.Name, "runtime.concatstring") {
+			skip[fn.AddrRange.Start] = true
+		}
+	}
+
+	var find func(data []byte, pc uint64) (Call, bool)
+	switch arch := f.Arch(); arch {
+	case exe.ArchAMD64:
+		find = func(data []byte, pc uint64) (Call, bool) {
+			return findAMD64Callee(data, pc, skip)
+		}
+	case exe.ArchARM64:
+		find = func(data []byte, pc uint64) (Call, bool) {
+			return findARM64Callee(data, pc, skip, f.ByteOrder())
+		}
+	default:
+		return nil, fmt.Errorf("unsupported architecture: %s", arch)
+	}
+
+	callees := make(map[uint64]Call)
+	for _, addr := range refAddrs {
+		if !txt.AddrRange.Contains(addr) {
+			continue
+		}
+		// the search is bounded by the function containing the reference
+		end := txt.AddrRange.End
+		if i := sort.Search(len(funcs), func(i int) bool { return funcs[i].AddrRange.Start > addr }); i < len(funcs) {
+			end = funcs[i].AddrRange.Start
+		}
+		off := addr - txt.AddrRange.Start
+		if limit := end - txt.AddrRange.Start; limit <= uint64(len(data)) && off < limit {
+			if call, ok := find(data[off:limit], addr); ok {
+				callees[addr] = call
+			}
+		}
+	}
+	return callees, nil
+}
+
+// findAMD64Callee decodes x86-64 instructions from the reference, returning the first direct call
+func findAMD64Callee(data []byte, pc uint64, skip map[uint64]bool) (Call, bool) {
+	for n, i := 0, 0; n < calleeWindow && i < len(data); n++ {
+		inst, err := x86asm.Decode(data[i:], 64)
+		if err != nil {
+			return Call{}, false
+		}
+		next := pc + uint64(i) + uint64(inst.Len)
+		switch inst.Op {
+		case x86asm.CALL:
+			rel, ok := inst.Args[0].(x86asm.Rel)
+			if !ok {
+				return Call{}, false // indirect calls cannot be resolved
+			}
+			if target := uint64(int64(next) + int64(rel)); !skip[target] {
+				return Call{Addr: pc + uint64(i), Target: target}, true
+			}
+		case x86asm.JMP, x86asm.RET, x86asm.INT:
+			return Call{}, false
+		}
+		i += inst.Len
+	}
+	return Call{}, false
+}
+
+// findARM64Callee decodes AArch64 instructions from the reference, returning the first `bl`
+func findARM64Callee(data []byte, pc uint64, skip map[uint64]bool, bo binary.ByteOrder) (Call, bool) {
+	for n := 0; n < calleeWindow && (n+1)*arm64.InstructionSize <= len(data); n++ {
+		insn := bo.Uint32(data[n*arm64.InstructionSize:])
+		insnPC := pc + uint64(n*arm64.InstructionSize)
+		if target, ok := arm64.DecodeBL(insn, insnPC); ok {
+			if !skip[target] {
+				return Call{Addr: insnPC, Target: target}, true
+			}
+			continue
+		}
+		if arm64.IsBranch(insn) {
+			return Call{}, false
+		}
+	}
+	return Call{}, false
+}
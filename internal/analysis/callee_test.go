@@ -0,0 +1,38 @@
+package analysis
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindAMD64Callee(t *testing.T) {
+	data := []byte{
+		0x48, 0x8d, 0x05, 0x75, 0x13, 0x00, 0x00, // lea rax, [rip + 0x1375]
+		0xbb, 0x06, 0x00, 0x00, 0x00, // mov ebx, 6
+		0xe8, 0x0f, 0x00, 0x00, 0x00, // call 0x1020 (write barrier)
+		0xe8, 0x1b, 0x00, 0x00, 0x00, // call 0x1031
+	}
+
+	call, ok := findAMD64Callee(data, 0x1000, map[uint64]bool{0x1020: true})
+	assert.True(t, ok)
+	assert.Equal(t, Call{Addr: 0x1011, Target: 0x1031}, call)
+
+	// a return ends the search
+	_, ok = findAMD64Callee([]byte{0xc3, 0xe8, 0x00, 0x00, 0x00, 0x00}, 0x1000, nil)
+	assert.False(t, ok)
+}
+
+func TestFindARM64Callee(t *testing.T) {
+	data := []byte{
+		0x20, 0x00, 0x00, 0xb0, // adrp x0, ...
+		0x00, 0xd8, 0x2c, 0x91, // add x0, x0, #0xb36
+		0x41, 0x01, 0x80, 0xd2, // mov x1, #10
+		0x8f, 0xd2, 0xff, 0x97, // bl os.Getenv
+	}
+
+	call, ok := findARM64Callee(data, 0xab078, nil, binary.LittleEndian)
+	assert.True(t, ok)
+	assert.Equal(t, Call{Addr: 0xab084, Target: 0x9fac0}, call)
+}
@@ -5,4 +5,23 @@ type Candidate struct {
 	Addr     uint64   // address where the string resides
 	Len      uint64   // length of the string
 	RefAddrs []uint64 // addresses that reference the string
+	// ArgSlots maps reference addresses to the register argument slot the string pointer is placed in. References
+	// that do not place the string in argument registers (e.g. struct fields) are absent.
+	ArgSlots map[uint64]int
+	// FieldOffsets maps reference addresses to the offset the string header is stored at, where the string is stored
+	// into a heap object (e.g. a struct field). References that do not store into heap objects are absent.
+	FieldOffsets map[uint64]int64
+	// Destinations maps reference addresses to the location the string pointer is placed in, which allows the variable
+	// or field it is assigned to to be named. References whose destination is not understood are absent.
+	Destinations map[uint64]Destination
+}
+
+// Destination is the location a string pointer is placed in: either a register, or memory at an offset from a base
+// register. Registers are identified by their DWARF register number, so are independent of the architecture.
+type Destination struct {
+	Reg    int    // register, or the base register for memory
+	Mem    bool   // whether the destination is memory
+	Offset int64  // offset from the base register, for memory
+	PC     uint64 // address of the instruction completing the placement
+	Next   uint64 // address of the following instruction, from which the destination holds the pointer
 }
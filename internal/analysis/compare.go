@@ -0,0 +1,126 @@
+package analysis
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/nick-jones/gost/internal/exe"
+)
+
+const (
+	// comparisonMaxLen is the longest string that the compiler compares against immediates. Longer strings are
+	// compared via runtime.memequal, which is passed the address of the string (so is found as a direct reference).
+	comparisonMaxLen = 16
+	// comparisonWindow is the maximum number of instructions between the length check of a comparison and the
+	// comparison of its final bytes
+	comparisonWindow = 24
+)
+
+// comparison is a string compared against a constant, along with the address of the comparison
+type comparison struct {
+	value   string
+	refAddr uint64
+}
+
+// EvaluateComparisons scans for comparisons of strings against constants, returning candidates for those constants
+// that are among the supplied candidates. Short constants are compared against immediates, so the string itself is
+// never referenced; the string may only be located if it is referenced elsewhere.
+func EvaluateComparisons(f *exe.File, candidates []Candidate) ([]Candidate, error) {
+	var (
+		comparisons []comparison
+		err         error
+	)
+	switch arch := f.Arch(); arch {
+	case exe.ArchAMD64:
+		comparisons, err = findAMD64Comparisons(f)
+	case exe.ArchARM64:
+		comparisons, err = findARM64Comparisons(f)
+	default:
+		return nil, fmt.Errorf("unsupported architecture: %s", arch)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	known, err := shortStrings(f, candidates)
+	if err != nil {
+		return nil, err
+	}
+	var compared []Candidate
+	for _, c := range comparisons {
+		if addr, found := known[c.value]; found {
+			compared = append(compared, Candidate{Addr: addr, Len: uint64(len(c.value)), RefAddrs: []uint64{c.refAddr}})
+		}
+	}
+	return compared, nil
+}
+
+// shortStrings reads the values of candidates short enough to be compared against immediates, returning a map of
+// value to address
+func shortStrings(f *exe.File, candidates []Candidate) (map[string]uint64, error) {
+	sect, err := f.RODataSection()
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]uint64)
+	for _, candidate := range candidates {
+		if candidate.Len == 0 || candidate.Len > comparisonMaxLen || !sect.AddrRange.Contains(candidate.Addr) ||
+			!sect.AddrRange.Contains(candidate.Addr+candidate.Len) {
+			continue
+		}
+		buf := make([]byte, candidate.Len)
+		if _, err := sect.ReadAt(buf, int64(candidate.Addr-sect.AddrRange.Start)); err != nil {
+			return nil, fmt.Errorf("failed to read data: %w", err)
+		}
+		if _, found := known[string(buf)]; !found {
+			known[string(buf)] = candidate.Addr
+		}
+	}
+	return known, nil
+}
+
+// pendingComparison accumulates the bytes of a string compared against a constant. The compiler checks the length of
+// the string first, then compares the bytes (addressed by the string pointer) against immediates up to a word at a
+// time. The pieces may be compared in any order, so long as they are compared within comparisonWindow instructions.
+type pendingComparison struct {
+	refAddr uint64 // address of the length check
+	index   int    // index of the length check
+	base    int    // register holding the string pointer, or -1 until the first piece is compared
+	value   []byte
+	filled  uint32 // bitmask of the bytes compared so far
+}
+
+// newPendingComparison initialises the pendingComparison type, for a string whose length is compared with the supplied
+// value. Nil is returned if the length cannot be that of a string compared against immediates.
+func newPendingComparison(length, refAddr uint64, index int) *pendingComparison {
+	if length == 0 || length > comparisonMaxLen {
+		return nil
+	}
+	return &pendingComparison{refAddr: refAddr, index: index, base: -1, value: make([]byte, length)}
+}
+
+// fits returns true if size bytes at an offset from the base register may be a piece of the string
+func (c *pendingComparison) fits(base int, offset int64, size int, index int) bool {
+	switch {
+	case index-c.index > comparisonWindow:
+		return false
+	case c.base >= 0 && base != c.base:
+		return false
+	default:
+		return offset >= 0 && offset+int64(size) <= int64(len(c.value))
+	}
+}
+
+// add records the comparison of size bytes at an offset from the base register against an immediate, returning true
+// once every byte of the string has been compared. The piece must fit the string.
+func (c *pendingComparison) add(base int, offset int64, size int, imm uint64, bo binary.ByteOrder) bool {
+	piece := make([]byte, 8)
+	bo.PutUint64(piece, imm)
+	if bo == binary.BigEndian {
+		piece = piece[8-size:]
+	}
+	copy(c.value[offset:], piece[:size])
+	c.base = base
+	c.filled |= (1<<size - 1) << offset
+	return c.filled == 1<<len(c.value)-1
+}
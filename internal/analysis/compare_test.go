@@ -0,0 +1,29 @@
+package analysis
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPendingComparison(t *testing.T) {
+	bo := binary.LittleEndian
+	c := newPendingComparison(6, 0x1000, 0)
+	if !assert.NotNil(t, c) {
+		return
+	}
+
+	// pieces may be compared in any order, but must share the base register and fit within the string
+	assert.True(t, c.fits(0, 4, 2, 1))
+	assert.False(t, c.add(0, 4, 2, 0x616e, bo)) // "na"
+	assert.False(t, c.fits(1, 0, 4, 2))
+	assert.False(t, c.fits(0, 4, 4, 2))
+	assert.False(t, c.fits(0, 0, 4, comparisonWindow+1))
+	assert.True(t, c.fits(0, 0, 4, 2))
+	assert.True(t, c.add(0, 0, 4, 0x616e6162, bo)) // "bana"
+	assert.Equal(t, "banana", string(c.value))
+
+	assert.Nil(t, newPendingComparison(0, 0x1000, 0))
+	assert.Nil(t, newPendingComparison(comparisonMaxLen+1, 0x1000, 0))
+}
@@ -6,7 +6,14 @@ import (
 
 	"github.com/nick-jones/gost/internal/address"
 	"github.com/nick-jones/gost/internal/exe"
-	"github.com/nick-jones/gost/internal/pattern"
+)
+
+const (
+	// typeKindOffset is the offset of the kind field within a type descriptor (runtime._type, later abi.Type). This
+	// follows the size, ptrdata, hash, tflag, align and fieldAlign fields on 64-bit platforms.
+	typeKindOffset = 23
+	// typeKindMask strips the flags that share the kind field (e.g. kindDirectIface, kindGCProg)
+	typeKindMask = 0x1f
 )
 
 // EvaluateIndirectReferences scans for indirect references to the supplied address range and returns candidates
@@ -16,31 +23,33 @@ func EvaluateIndirectReferences(f *exe.File, strRange *address.Range) ([]Candida
 		return nil, err
 	}
 
-	sect, err := f.RODataSection()
-	if err != nil {
-		return nil, err
-	}
-
 	typeBuf := make([]byte, 1)
 	strPtrBuf := make([]byte, 8)
 	strLenBuf := make([]byte, 8)
 	candidates := make([]Candidate, 0)
 	for _, ref := range refs {
-		if !sect.AddrRange.Contains(ref.typeAddr) || !sect.AddrRange.Contains(ref.valueHeaderAddr) {
+		// Types have historically lived in rodata, but newer toolchains place them in a section of their own. So rather
+		// than assume a particular section, locate whichever contains the type & value header.
+		typeSect, err := f.SectionContainingRange(address.Range{Start: ref.typeAddr, End: ref.typeAddr + typeKindOffset})
+		if err != nil {
+			continue
+		}
+		sect, err := f.SectionContainingRange(address.Range{Start: ref.valueHeaderAddr, End: ref.valueHeaderAddr + 16})
+		if err != nil {
 			continue
 		}
 
-		// check type
-		if _, err := sect.ReadAt(typeBuf, int64(ref.typeAddr-sect.AddrRange.Start+23)); err != nil {
-			return nil, err
+		// check type. Sections without file data (e.g. .bss) cannot be read, and such pairs are of no interest anyway.
+		if _, err := typeSect.ReadAt(typeBuf, int64(ref.typeAddr-typeSect.AddrRange.Start+typeKindOffset)); err != nil {
+			continue
 		}
-		if reflect.Kind(typeBuf[0]) != reflect.String {
+		if reflect.Kind(typeBuf[0]&typeKindMask) != reflect.String {
 			continue
 		}
 
 		// read pointer and check address
 		if _, err := sect.ReadAt(strPtrBuf, int64(ref.valueHeaderAddr-sect.AddrRange.Start)); err != nil {
-			return nil, err
+			continue
 		}
 		strPtr := readUint64(strPtrBuf, f.ByteOrder())
 		if strRange != nil && !strRange.Contains(strPtr) {
@@ -49,7 +58,7 @@ func EvaluateIndirectReferences(f *exe.File, strRange *address.Range) ([]Candida
 
 		// read len
 		if _, err := sect.ReadAt(strLenBuf, int64(ref.valueHeaderAddr-sect.AddrRange.Start+8)); err != nil {
-			return nil, err
+			continue
 		}
 		strLen := readUint64(strLenBuf, f.ByteOrder())
 
@@ -68,62 +77,15 @@ type interfaceReference struct {
 	valueHeaderAddr uint64
 }
 
+// findInterfaceReferences locates instructions that reference a type and value header pair, as is seen when
+// converting a value to an interface. The instructions searched for depend on the architecture of the executable.
 func findInterfaceReferences(f *exe.File) ([]interfaceReference, error) {
-	// the __text section contains executable instructions
-	txt, err := f.TextSection()
-	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve text section: %w", err)
+	switch arch := f.Arch(); arch {
+	case exe.ArchAMD64:
+		return findAMD64InterfaceReferences(f)
+	case exe.ArchARM64:
+		return findARM64InterfaceReferences(f)
+	default:
+		return nil, fmt.Errorf("unsupported architecture: %s", arch)
 	}
-
-	// read data for this section
-	data, err := txt.Data()
-	if err != nil {
-		return nil, fmt.Errorf("could not read data from text section: %w", err)
-	}
-
-	// copy out the patterns
-	patterns := make([][]byte, len(directMatchers))
-	for i, matcher := range indirectMatchers {
-		patterns[i] = matcher.pattern
-	}
-
-	// search __text section for string candidates
-	matched := pattern.MatchBytes(data, patterns)
-
-	references := make([]interfaceReference, 0)
-	for _, m := range matched {
-		matcher := indirectMatchers[m.Pattern] // locate original indirectMatcher
-
-		var (
-			arg1, arg2   int
-			skipArgCheck = true
-		)
-		if matcher.arg1Pos >= 0 {
-			// extract the stack pointer offset for the first argument (pointer to string value). If a position is not
-			// supplied, we assume zero offset
-			arg1 = int(data[m.Index+matcher.arg1Pos])
-			skipArgCheck = false
-		}
-		if matcher.arg2Pos >= 0 {
-			// extract the stack pointer offset for the second argument (string length)
-			arg2 = int(data[m.Index+matcher.arg2Pos])
-			skipArgCheck = false
-		}
-
-		if (arg1%8 == 0 && arg2 == arg1+8) || skipArgCheck {
-			refAddr := txt.AddrRange.Start + uint64(m.Index+matcher.insPos)
-			typeRelAddr := txt.AddrRange.Start + uint64(m.Index+matcher.typeOffsetPos+matcher.typeOffsetLen)
-			typeOffset := uint64(readUint32(data[m.Index+matcher.typeOffsetPos:m.Index+matcher.typeOffsetPos+matcher.typeOffsetLen], f.ByteOrder()))
-			valueHeaderRelAddr := txt.AddrRange.Start + uint64(m.Index+matcher.valueHeaderOffsetPos+matcher.valueHeaderOffsetLen)
-			valueHeaderOffset := uint64(readUint32(data[m.Index+matcher.valueHeaderOffsetPos:m.Index+matcher.valueHeaderOffsetPos+matcher.valueHeaderOffsetLen], f.ByteOrder()))
-
-			references = append(references, interfaceReference{
-				addr:            refAddr,
-				typeAddr:        typeRelAddr + typeOffset,
-				valueHeaderAddr: valueHeaderRelAddr + valueHeaderOffset,
-			})
-		}
-	}
-
-	return references, nil
 }
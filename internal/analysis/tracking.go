@@ -0,0 +1,24 @@
+package analysis
+
+type valueKind int
+
+const (
+	valueNone valueKind = iota
+	valuePage           // holds a page address (arm64 adrp), which is not yet a usable address
+	valueAddr           // holds an address relative to the instruction pointer
+	valueImm            // holds an immediate value
+)
+
+// trackedValue carries what is known about the contents of a register or memory location
+type trackedValue struct {
+	kind    valueKind
+	value   uint64
+	refAddr uint64 // address of the instruction that started materialising the value
+	index   int    // index of the instruction that completed the value
+}
+
+// available returns true if the value is of the supplied kind and was set no more than window instructions before
+// the supplied instruction index
+func (v trackedValue) available(kind valueKind, index, window int) bool {
+	return v.kind == kind && index-v.index <= window
+}
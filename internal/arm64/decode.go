@@ -0,0 +1,272 @@
+// Package arm64 decodes the handful of AArch64 instructions that Go uses to materialise string pointers and lengths,
+// and to compare strings against constants. It is not a general purpose disassembler; anything it does not recognise
+// is reported as not ok.
+package arm64
+
+// InstructionSize is the size in bytes of every AArch64 instruction
+const InstructionSize = 4
+
+// ZR is the register number used by the zero register (and stack pointer, depending on the instruction)
+const ZR = 31
+
+// SP is the register number of the stack pointer, when used as a base register
+const SP = 31
+
+// FP is the register number of the frame pointer (x29)
+const FP = 29
+
+// DecodeADRP decodes `adrp xd, label`, returning the destination register and the page address it is set to. The pc
+// value must be the address of the instruction itself.
+func DecodeADRP(insn uint32, pc uint64) (rd int, addr uint64, ok bool) {
+	if insn&0x9f000000 != 0x90000000 {
+		return 0, 0, false
+	}
+	immlo := uint64(insn>>29) & 0x3
+	immhi := uint64(insn>>5) & 0x7ffff
+	imm := signExtend(immhi<<2|immlo, 21) << 12
+	return int(insn & 0x1f), (pc &^ 0xfff) + imm, true
+}
+
+// DecodeADDImmediate decodes the 64-bit form of `add xd, xn, #imm{, lsl #12}`
+func DecodeADDImmediate(insn uint32) (rd, rn int, imm uint64, ok bool) {
+	if insn&0xff800000 != 0x91000000 {
+		return 0, 0, 0, false
+	}
+	imm = uint64(insn>>10) & 0xfff
+	if insn&(1<<22) != 0 {
+		imm <<= 12
+	}
+	return int(insn & 0x1f), int(insn>>5) & 0x1f, imm, true
+}
+
+// DecodeMOVImmediate decodes instructions that set a register to a constant. This covers `movz` (both 32 and 64-bit)
+// and `orr xd, xzr, #imm`, which is the form Go's assembler prefers for values representable as bitmask immediates.
+func DecodeMOVImmediate(insn uint32) (rd int, imm uint64, ok bool) {
+	rd = int(insn & 0x1f)
+	switch {
+	case insn&0x7f800000 == 0x52800000: // movz
+		hw := uint64(insn>>21) & 0x3
+		if insn&(1<<31) == 0 && hw > 1 {
+			return 0, 0, false // unallocated for 32-bit registers
+		}
+		return rd, (uint64(insn>>5) & 0xffff) << (16 * hw), true
+	case insn&0x7f800000 == 0x32000000: // orr (immediate)
+		if int(insn>>5)&0x1f != ZR {
+			return 0, 0, false
+		}
+		n := uint64(insn>>22) & 0x1
+		immr := uint64(insn>>16) & 0x3f
+		imms := uint64(insn>>10) & 0x3f
+		width := uint64(64)
+		if insn&(1<<31) == 0 {
+			if n != 0 {
+				return 0, 0, false
+			}
+			width = 32
+		}
+		imm, ok := decodeBitMask(n, imms, immr, width)
+		return rd, imm, ok
+	default:
+		return 0, 0, false
+	}
+}
+
+// DecodeSTP decodes the 64-bit forms of `stp xt, xt2, [xn, #imm]` (including the pre/post index & non-temporal
+// variants), returning the pair of registers that are stored, along with the base register and offset of the store
+func DecodeSTP(insn uint32) (rt, rt2, rn int, offset int64, ok bool) {
+	if insn&0xfc400000 != 0xa8000000 {
+		return 0, 0, 0, 0, false
+	}
+	rt, rt2, rn, offset = decodePair(insn)
+	return rt, rt2, rn, offset, true
+}
+
+// DecodeLDP decodes the 64-bit forms of `ldp xt, xt2, [xn, #imm]`, returning the pair of registers that are loaded,
+// along with the base register and offset of the load. Variants are as DecodeSTP.
+func DecodeLDP(insn uint32) (rt, rt2, rn int, offset int64, ok bool) {
+	if insn&0xfc400000 != 0xa8400000 {
+		return 0, 0, 0, 0, false
+	}
+	rt, rt2, rn, offset = decodePair(insn)
+	return rt, rt2, rn, offset, true
+}
+
+// decodePair decodes the operands shared by the load & store pair instructions
+func decodePair(insn uint32) (rt, rt2, rn int, offset int64) {
+	// post-index accesses the base address, adjusting the base afterwards
+	if insn>>23&0x3 != 0x1 {
+		offset = int64(signExtend(uint64(insn>>15&0x7f), 7)) * 8
+	}
+	return int(insn & 0x1f), int(insn>>10) & 0x1f, int(insn>>5) & 0x1f, offset
+}
+
+// DecodeLDR decodes the zero extending loads of a single register, `ldrb`, `ldrh` and `ldr` (both 32 and 64-bit),
+// with an unsigned or unscaled offset. The size in bytes of the value loaded is returned.
+func DecodeLDR(insn uint32) (rt, rn int, offset int64, size int, ok bool) {
+	size = 1 << (insn >> 30)
+	switch {
+	case insn&0x3fc00000 == 0x39400000: // unsigned offset, scaled by the size
+		offset = int64(insn>>10&0xfff) * int64(size)
+	case insn&0x3fe00c00 == 0x38400000: // unscaled offset (ldur)
+		offset = int64(signExtend(uint64(insn>>12&0x1ff), 9))
+	default:
+		return 0, 0, 0, 0, false
+	}
+	return int(insn & 0x1f), int(insn>>5) & 0x1f, offset, size, true
+}
+
+// DecodeMOVK decodes `movk` (both 32 and 64-bit), returning the 16 bits set in the register along with their shift.
+// Unlike `movz`, the remaining bits of the register are kept.
+func DecodeMOVK(insn uint32) (rd int, imm uint64, shift uint, ok bool) {
+	if insn&0x7f800000 != 0x72800000 {
+		return 0, 0, 0, false
+	}
+	hw := uint(insn>>21) & 0x3
+	if insn&(1<<31) == 0 && hw > 1 {
+		return 0, 0, 0, false // unallocated for 32-bit registers
+	}
+	return int(insn & 0x1f), uint64(insn>>5) & 0xffff, 16 * hw, true
+}
+
+// DecodeCMPImmediate decodes `cmp xn, #imm{, lsl #12}` (both 32 and 64-bit), an alias of `subs` with the zero
+// register as the destination
+func DecodeCMPImmediate(insn uint32) (rn int, imm uint64, ok bool) {
+	if insn&0x7f80001f != 0x7100001f {
+		return 0, 0, false
+	}
+	imm = uint64(insn>>10) & 0xfff
+	if insn&(1<<22) != 0 {
+		imm <<= 12
+	}
+	return int(insn>>5) & 0x1f, imm, true
+}
+
+// DecodeCMPRegister decodes `cmp xn, xm` (both 32 and 64-bit), an alias of `subs` with the zero register as the
+// destination. Shifted forms are not recognised.
+func DecodeCMPRegister(insn uint32) (rn, rm int, ok bool) {
+	if insn&0x7f20fc1f != 0x6b00001f {
+		return 0, 0, false
+	}
+	return int(insn>>5) & 0x1f, int(insn>>16) & 0x1f, true
+}
+
+// decodeBitMask implements DecodeBitMasks from the Arm architecture reference manual, for the immediate case only
+func decodeBitMask(n, imms, immr, width uint64) (uint64, bool) {
+	// the element size is derived from the highest set bit of N:NOT(imms)
+	combined := n<<6 | (^imms & 0x3f)
+	length := -1
+	for i := 6; i >= 0; i-- {
+		if combined&(1<<uint(i)) != 0 {
+			length = i
+			break
+		}
+	}
+	if length < 1 {
+		return 0, false
+	}
+	size := uint64(1) << uint(length)
+	levels := size - 1
+	s := imms & levels
+	r := immr & levels
+	if s == levels {
+		return 0, false // reserved: all ones is not a valid bitmask immediate
+	}
+
+	// build the element, rotate it right by r and then replicate it across the register width
+	elem := uint64(1)<<(s+1) - 1
+	if r != 0 {
+		elem = (elem>>r | elem<<(size-r)) & ones(size)
+	}
+	var imm uint64
+	for i := uint64(0); i < width; i += size {
+		imm |= elem << i
+	}
+	return imm & ones(width), true
+}
+
+// ones returns a value with the lowest n bits set
+func ones(n uint64) uint64 {
+	if n >= 64 {
+		return ^uint64(0)
+	}
+	return uint64(1)<<n - 1
+}
+
+// signExtend sign extends the lowest `bits` bits of v
+func signExtend(v uint64, bits uint) uint64 {
+	shift := 64 - bits
+	return uint64(int64(v<<shift) >> shift)
+}
+
+// DecodeBL decodes `bl label`, returning the address of the function called
+func DecodeBL(insn uint32, pc uint64) (target uint64, ok bool) {
+	if insn&0xfc000000 != 0x94000000 {
+		return 0, false
+	}
+	return pc + signExtend(uint64(insn&0x3ffffff)<<2, 28), true
+}
+
+// IsBranch returns true for unconditional branches, calls and returns (`b`, `bl`, `br`, `blr` & `ret`)
+func IsBranch(insn uint32) bool {
+	switch {
+	case insn&0x7c000000 == 0x14000000: // b, bl
+		return true
+	case insn&0xff9ffc1f == 0xd61f0000: // br, blr, ret
+		return true
+	default:
+		return false
+	}
+}
+
+// DecodeWrittenRegisters returns the general purpose registers written by an instruction (-1 where there are fewer
+// than 2), so that the values previously known to be held in them can be forgotten. This errs on the side of
+// reporting a register as written where the encoding is ambiguous. Writes to the zero register, SIMD & floating
+// point registers, and the base register written back by pre & post indexed addressing, are not reported. ok is
+// false if the instruction writes no general purpose register.
+func DecodeWrittenRegisters(insn uint32) (rd, rd2 int, ok bool) {
+	rd, rd2 = -1, -1
+	rt := int(insn & 0x1f)
+	switch {
+	case insn&0x1c000000 == 0x10000000: // data processing (immediate)
+		rd = rt
+	case insn&0x0e000000 == 0x0a000000: // data processing (register)
+		if insn&0x3fe00000 != 0x3a400000 { // conditional compares only set flags
+			rd = rt
+		}
+	case insn&0x5f20fc00 == 0x1e200000: // conversion between floating point & integer, either direction
+		rd = rt
+	case insn&0xfff00000 == 0xd5300000: // mrs
+		rd = rt
+	case insn&0x0a000000 == 0x08000000 && insn&(1<<26) == 0: // loads & stores of general purpose registers
+		switch {
+		case insn&0x3b000000 == 0x18000000: // load literal
+			if insn>>30 != 0x3 { // prfm
+				rd = rt
+			}
+		case insn&0x3a000000 == 0x28000000: // pairs
+			if insn&(1<<22) != 0 {
+				rd, rd2 = rt, int(insn>>10)&0x1f
+			}
+		case insn&0x3a000000 == 0x38000000: // single registers, including atomic memory operations
+			if insn>>22&0x3 != 0 {
+				rd = rt
+			}
+		case insn&0x3f000000 == 0x08000000: // exclusive & ordered
+			if insn&(1<<22) != 0 {
+				rd = rt
+			} else {
+				rd = int(insn>>16) & 0x1f // the status register of store exclusive
+			}
+		}
+	}
+	if rd == ZR {
+		rd = -1
+	}
+	if rd2 == ZR {
+		rd2 = -1
+	}
+	if rd < 0 {
+		rd, rd2 = rd2, -1
+	}
+	return rd, rd2, rd >= 0
+}
@@ -0,0 +1,233 @@
+package arm64_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nick-jones/gost/internal/arm64"
+)
+
+func TestDecodeADRP(t *testing.T) {
+	rd, addr, ok := arm64.DecodeADRP(0xd0000060, 0xa2080) // adrp x0, 57344(pc)
+	assert.True(t, ok)
+	assert.Equal(t, 0, rd)
+	assert.Equal(t, uint64(0xb0000), addr)
+
+	_, _, ok = arm64.DecodeADRP(0x913de063, 0xa2080) // add x3, x3, #3960
+	assert.False(t, ok)
+}
+
+func TestDecodeADDImmediate(t *testing.T) {
+	rd, rn, imm, ok := arm64.DecodeADDImmediate(0x913de063) // add x3, x3, #3960
+	assert.True(t, ok)
+	assert.Equal(t, 3, rd)
+	assert.Equal(t, 3, rn)
+	assert.Equal(t, uint64(3960), imm)
+
+	_, _, imm, ok = arm64.DecodeADDImmediate(0x91400400) // add x0, x0, #1, lsl #12
+	assert.True(t, ok)
+	assert.Equal(t, uint64(4096), imm)
+}
+
+func TestDecodeMOVImmediate(t *testing.T) {
+	testCases := []struct {
+		name string
+		insn uint32
+		rd   int
+		imm  uint64
+		ok   bool
+	}{
+		{
+			name: "movz x1, #5",
+			insn: 0xd28000a1,
+			rd:   1,
+			imm:  5,
+			ok:   true,
+		},
+		{
+			name: "movz w2, #300",
+			insn: 0x52802582,
+			rd:   2,
+			imm:  300,
+			ok:   true,
+		},
+		{
+			name: "orr x1, xzr, #1",
+			insn: 0xb24003e1,
+			rd:   1,
+			imm:  1,
+			ok:   true,
+		},
+		{
+			name: "orr x1, xzr, #6",
+			insn: 0xb27f07e1,
+			rd:   1,
+			imm:  6,
+			ok:   true,
+		},
+		{
+			name: "orr x1, x2, #1 (not a move)",
+			insn: 0xb2400041,
+			ok:   false,
+		},
+		{
+			name: "stp x3, x4, [sp, #40]",
+			insn: 0xa90293e3,
+			ok:   false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rd, imm, ok := arm64.DecodeMOVImmediate(tc.insn)
+			assert.Equal(t, tc.ok, ok)
+			if tc.ok {
+				assert.Equal(t, tc.rd, rd)
+				assert.Equal(t, tc.imm, imm)
+			}
+		})
+	}
+}
+
+func TestDecodeSTP(t *testing.T) {
+	rt, rt2, rn, offset, ok := arm64.DecodeSTP(0xa90293e3) // stp x3, x4, [sp, #40]
+	assert.True(t, ok)
+	assert.Equal(t, 3, rt)
+	assert.Equal(t, 4, rt2)
+	assert.Equal(t, 31, rn)
+	assert.Equal(t, int64(40), offset)
+
+	_, _, _, offset, ok = arm64.DecodeSTP(0xa9bf7bfd) // stp x29, x30, [sp, #-16]!
+	assert.True(t, ok)
+	assert.Equal(t, int64(-16), offset)
+
+	_, _, _, _, ok = arm64.DecodeSTP(0xd28000a1) // movz x1, #5
+	assert.False(t, ok)
+}
+
+func TestDecodeBL(t *testing.T) {
+	target, ok := arm64.DecodeBL(0x97ffed5e, 0xa2084) // bl fmt.Println
+	assert.True(t, ok)
+	assert.Equal(t, uint64(0x9d5fc), target)
+
+	_, ok = arm64.DecodeBL(0x17ffffe6, 0xa2084) // b main.main
+	assert.False(t, ok)
+}
+
+func TestIsBranch(t *testing.T) {
+	assert.True(t, arm64.IsBranch(0x97ffed5e))  // bl fmt.Println
+	assert.True(t, arm64.IsBranch(0x17ffffe6))  // b main.main
+	assert.True(t, arm64.IsBranch(0xd65f03c0))  // ret
+	assert.False(t, arm64.IsBranch(0xd28000a1)) // movz x1, #5
+}
+
+func TestDecodeWrittenRegisters(t *testing.T) {
+	testCases := []struct {
+		name    string
+		insn    uint32
+		rd, rd2 int
+		ok      bool
+	}{
+		{name: "ldr x1, [sp, #40]", insn: 0xf94017e1, rd: 1, rd2: -1, ok: true},
+		{name: "ldr w1, [x27, #1856]", insn: 0xb9474361, rd: 1, rd2: -1, ok: true},
+		{name: "ldp x1, x2, [sp]", insn: 0xa9400be1, rd: 1, rd2: 2, ok: true},
+		{name: "mov x1, x0", insn: 0xaa0003e1, rd: 1, rd2: -1, ok: true},
+		{name: "sub x29, sp, #8", insn: 0xd10023fd, rd: 29, rd2: -1, ok: true},
+		{name: "str x1, [x0]", insn: 0xf9000001, rd: -1, rd2: -1},
+		{name: "stp x4, x3, [x25]", insn: 0xa9000f24, rd: -1, rd2: -1},
+		{name: "cmp sp, x16", insn: 0xeb3063ff, rd: -1, rd2: -1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rd, rd2, ok := arm64.DecodeWrittenRegisters(tc.insn)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.rd, rd)
+			assert.Equal(t, tc.rd2, rd2)
+		})
+	}
+}
+
+func TestDecodeLDP(t *testing.T) {
+	rt, rt2, rn, offset, ok := arm64.DecodeLDP(0xa9400801) // ldp x1, x2, [x0]
+	assert.True(t, ok)
+	assert.Equal(t, 1, rt)
+	assert.Equal(t, 2, rt2)
+	assert.Equal(t, 0, rn)
+	assert.Equal(t, int64(0), offset)
+
+	_, _, _, _, ok = arm64.DecodeLDP(0xa90293e3) // stp x3, x4, [sp, #40]
+	assert.False(t, ok)
+}
+
+func TestDecodeLDR(t *testing.T) {
+	testCases := []struct {
+		name   string
+		insn   uint32
+		rt, rn int
+		offset int64
+		size   int
+		ok     bool
+	}{
+		{name: "ldrb w1, [x0, #2]", insn: 0x39400801, rt: 1, rn: 0, offset: 2, size: 1, ok: true},
+		{name: "ldrh w1, [x0]", insn: 0x79400001, rt: 1, rn: 0, offset: 0, size: 2, ok: true},
+		{name: "ldr w1, [x27, #1856]", insn: 0xb9474361, rt: 1, rn: 27, offset: 1856, size: 4, ok: true},
+		{name: "ldr x1, [sp, #40]", insn: 0xf94017e1, rt: 1, rn: 31, offset: 40, size: 8, ok: true},
+		{name: "ldur x1, [x0, #-8]", insn: 0xf85f8001, rt: 1, rn: 0, offset: -8, size: 8, ok: true},
+		{name: "str x1, [x0]", insn: 0xf9000001},
+		{name: "ldrsb x1, [x0]", insn: 0x39800001},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rt, rn, offset, size, ok := arm64.DecodeLDR(tc.insn)
+			assert.Equal(t, tc.ok, ok)
+			if tc.ok {
+				assert.Equal(t, tc.rt, rt)
+				assert.Equal(t, tc.rn, rn)
+				assert.Equal(t, tc.offset, offset)
+				assert.Equal(t, tc.size, size)
+			}
+		})
+	}
+}
+
+func TestDecodeMOVK(t *testing.T) {
+	rd, imm, shift, ok := arm64.DecodeMOVK(0xf2ac8c62) // movk x2, #25699, lsl #16
+	assert.True(t, ok)
+	assert.Equal(t, 2, rd)
+	assert.Equal(t, uint64(25699), imm)
+	assert.Equal(t, uint(16), shift)
+
+	_, _, _, ok = arm64.DecodeMOVK(0xd28c4c22) // movz x2, #25185
+	assert.False(t, ok)
+}
+
+func TestDecodeCMPImmediate(t *testing.T) {
+	rn, imm, ok := arm64.DecodeCMPImmediate(0xf1000c3f) // cmp x1, #3
+	assert.True(t, ok)
+	assert.Equal(t, 1, rn)
+	assert.Equal(t, uint64(3), imm)
+
+	rn, imm, ok = arm64.DecodeCMPImmediate(0x71018c3f) // cmp w1, #99
+	assert.True(t, ok)
+	assert.Equal(t, 1, rn)
+	assert.Equal(t, uint64(99), imm)
+
+	_, _, ok = arm64.DecodeCMPImmediate(0xd10023fd) // sub x29, sp, #8
+	assert.False(t, ok)
+}
+
+func TestDecodeCMPRegister(t *testing.T) {
+	rn, rm, ok := arm64.DecodeCMPRegister(0xeb02003f) // cmp x1, x2
+	assert.True(t, ok)
+	assert.Equal(t, 1, rn)
+	assert.Equal(t, 2, rm)
+
+	rn, rm, ok = arm64.DecodeCMPRegister(0x6b02003f) // cmp w1, w2
+	assert.True(t, ok)
+	assert.Equal(t, 1, rn)
+	assert.Equal(t, 2, rm)
+
+	_, _, ok = arm64.DecodeCMPRegister(0xeb3063ff) // cmp sp, x16
+	assert.False(t, ok)
+}
@@ -0,0 +1,375 @@
+// Package dwarfvar names the variables & struct fields that values are placed in, using the variable locations and
+// types recorded in the DWARF debugging information.
+//
+// The compiler records where each variable lives over the course of its function: a register, a stack slot (relative
+// to the canonical frame address, or CFA) or, for multi-word values such as strings, a piece in each. A value placed in
+// a register or stack slot is matched against these. A value stored at an offset from a base register is matched
+// against pointer variables held in that register, and the offset resolved to a field of the pointed-to struct.
+package dwarfvar
+
+import (
+	"debug/dwarf"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nick-jones/gost/internal/analysis"
+	"github.com/nick-jones/gost/internal/exe"
+)
+
+// Target is a variable, or field of a variable, that a value is placed in
+type Target struct {
+	Expr string // e.g. endpoint, cfg.Endpoint or opts.Server.Addr
+	Type string // type of the struct holding the field (e.g. main.Config), or empty if the target is a whole variable
+}
+
+// Resolver names the targets of destinations
+type Resolver struct {
+	data     *dwarf.Data
+	bo       binary.ByteOrder
+	loc      []byte // location lists: .debug_loclists (DWARF 5) or .debug_loc (DWARF 4)
+	loclists bool
+	addr     []byte // .debug_addr, holding addresses referenced by index from DWARF 5 location lists
+
+	sp      int                           // DWARF register number of the stack pointer
+	cfa     func(pc uint64) (int64, bool) // offset of the CFA from the stack pointer
+	funcs   []subprogram                  // ordered by address
+	vars    map[dwarf.Offset][]variable   // variables of each function, decoded on demand
+	origins map[dwarf.Offset]*dwarf.Entry // abstract origins of inlined variables
+}
+
+// subprogram is a function with code
+type subprogram struct {
+	low, high uint64
+	offset    dwarf.Offset
+	cu        compileUnit
+}
+
+// compileUnit carries the attributes of a compilation unit that location lists are decoded with
+type compileUnit struct {
+	base     uint64 // base address, which location list entries may be relative to
+	addrBase uint64 // offset of the unit's addresses within .debug_addr
+}
+
+// variable is a variable or parameter, along with the locations it is held in
+type variable struct {
+	name string
+	typ  dwarf.Type
+	locs []location
+}
+
+// location is where a variable is held over a range of addresses
+type location struct {
+	low, high uint64
+	pieces    []piece
+}
+
+// piece is where part of a variable is held. Pieces are laid out in order, so a piece's offset within the variable
+// is the sum of the sizes preceding it.
+type piece struct {
+	reg    int   // register, or -1 if held on the stack (or nowhere, e.g. optimised out)
+	stack  bool  // whether held on the stack, at an offset from the CFA
+	offset int64 // offset from the CFA, for the stack
+	size   int64 // size of the piece, or 0 if it covers the whole variable
+}
+
+// New prepares to resolve targets for the executable. spDelta returns the size of the stack frame at an address,
+// excluding any return address (see inline.Table.SPDelta). An error is returned if the binary carries no DWARF.
+func New(f *exe.File, spDelta func(pc uint64) (int64, bool)) (*Resolver, error) {
+	data, err := f.DWARF()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DWARF: %w", err)
+	}
+
+	r := &Resolver{
+		data:    data,
+		bo:      f.ByteOrder(),
+		vars:    make(map[dwarf.Offset][]variable),
+		origins: make(map[dwarf.Offset]*dwarf.Entry),
+	}
+	if r.loc, err = f.DWARFSection("loclists"); err == nil {
+		r.loclists = true
+		if r.addr, err = f.DWARFSection("addr"); err != nil {
+			return nil, fmt.Errorf("failed to read .debug_addr: %w", err)
+		}
+	} else if r.loc, err = f.DWARFSection("loc"); err != nil && !errors.Is(err, exe.ErrSectionNotFound) {
+		return nil, fmt.Errorf("failed to read .debug_loc: %w", err)
+	}
+
+	// the CFA is the stack pointer of the caller, prior to the call. On x86-64 the call pushes the return address,
+	// which is not included in the frame size. On arm64 the return address is held in the link register.
+	var retAddr int64
+	switch f.Arch() {
+	case exe.ArchAMD64:
+		r.sp, retAddr = 7, 8
+	case exe.ArchARM64:
+		r.sp = 31
+	default:
+		return nil, fmt.Errorf("unsupported architecture: %s", f.Arch())
+	}
+	r.cfa = func(pc uint64) (int64, bool) {
+		delta, ok := spDelta(pc)
+		return delta + retAddr, ok
+	}
+
+	if err := r.indexFunctions(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// indexFunctions records the address range of every function
+func (r *Resolver) indexFunctions() error {
+	var cu compileUnit
+	rd := r.data.Reader()
+	for {
+		entry, err := rd.Next()
+		if err != nil {
+			return fmt.Errorf("failed to read DWARF entry: %w", err)
+		}
+		if entry == nil {
+			break
+		}
+		switch entry.Tag {
+		case dwarf.TagCompileUnit:
+			cu = compileUnit{}
+			if low, ok := entry.Val(dwarf.AttrLowpc).(uint64); ok {
+				cu.base = low
+			}
+			if base, ok := entry.Val(dwarf.AttrAddrBase).(int64); ok {
+				cu.addrBase = uint64(base)
+			}
+			continue // functions are children of the unit
+		case dwarf.TagSubprogram:
+			ranges, err := r.data.Ranges(entry)
+			if err == nil && len(ranges) > 0 {
+				r.funcs = append(r.funcs, subprogram{low: ranges[0][0], high: ranges[0][1], offset: entry.Offset, cu: cu})
+			}
+		}
+		rd.SkipChildren()
+	}
+	sort.Slice(r.funcs, func(i, j int) bool {
+		return r.funcs[i].low < r.funcs[j].low
+	})
+	return nil
+}
+
+// Resolve names the target of a destination, if a variable is known to occupy it
+func (r *Resolver) Resolve(d analysis.Destination) (Target, bool) {
+	fn, ok := r.function(d.PC)
+	if !ok {
+		return Target{}, false
+	}
+	vars := r.variables(fn)
+	if d.Mem && d.Reg != r.sp {
+		return resolvePointee(vars, d)
+	}
+
+	// registers & stack slots hold the value from the following instruction
+	var cfaOffset int64
+	if d.Mem {
+		cfa, ok := r.cfa(d.PC)
+		if !ok {
+			return Target{}, false
+		}
+		cfaOffset = d.Offset - cfa
+	}
+	return resolveValue(vars, d, cfaOffset)
+}
+
+// resolvePointee names the target of a store via a base register, which holds a pointer, by looking for a pointer
+// variable in that register when the store is made
+func resolvePointee(vars []variable, d analysis.Destination) (Target, bool) {
+	for _, v := range vars {
+		ptr, ok := underlying(v.typ).(*dwarf.PtrType)
+		if !ok {
+			continue
+		}
+		for _, loc := range v.locs {
+			if !loc.covers(d.PC) || len(loc.pieces) == 0 || loc.pieces[0].reg != d.Reg {
+				continue
+			}
+			if path, owner, ok := fieldPath(ptr.Type, d.Offset); ok && path != "" {
+				return Target{Expr: v.name + path, Type: owner}, true
+			}
+		}
+	}
+	return Target{}, false
+}
+
+// resolveValue names the target of a register, or of a stack slot at the supplied offset from the CFA, by looking for
+// the piece of a variable held there
+func resolveValue(vars []variable, d analysis.Destination, cfaOffset int64) (Target, bool) {
+	for _, v := range vars {
+		for _, loc := range v.locs {
+			if !loc.covers(d.Next) {
+				continue
+			}
+			var pos int64 // offset of the piece within the variable
+			for _, p := range loc.pieces {
+				size := p.size
+				if size == 0 {
+					size = v.typ.Size()
+				}
+				var within int64
+				switch {
+				case !d.Mem && !p.stack && p.reg == d.Reg:
+				case d.Mem && p.stack && cfaOffset >= p.offset && cfaOffset < p.offset+size:
+					within = cfaOffset - p.offset
+				default:
+					pos += size
+					continue
+				}
+				if path, owner, ok := fieldPath(v.typ, pos+within); ok {
+					return Target{Expr: v.name + path, Type: owner}, true
+				}
+				pos += size
+			}
+		}
+	}
+	return Target{}, false
+}
+
+// function returns the function containing the address
+func (r *Resolver) function(pc uint64) (subprogram, bool) {
+	i := sort.Search(len(r.funcs), func(i int) bool {
+		return r.funcs[i].low > pc
+	}) - 1
+	if i < 0 || pc >= r.funcs[i].high {
+		return subprogram{}, false
+	}
+	return r.funcs[i], true
+}
+
+// variables returns the variables & parameters of a function, including those of nested blocks & inlined calls.
+// Entries that cannot be decoded are omitted.
+func (r *Resolver) variables(fn subprogram) []variable {
+	if vars, found := r.vars[fn.offset]; found {
+		return vars
+	}
+
+	var vars []variable
+	rd := r.data.Reader()
+	rd.Seek(fn.offset)
+	if entry, err := rd.Next(); err == nil && entry != nil && entry.Children {
+		for depth := 1; depth > 0; {
+			entry, err := rd.Next()
+			if err != nil || entry == nil {
+				break
+			}
+			switch {
+			case entry.Tag == 0:
+				depth--
+				continue
+			case entry.Children:
+				depth++
+			}
+			if entry.Tag != dwarf.TagVariable && entry.Tag != dwarf.TagFormalParameter {
+				continue
+			}
+			if v, ok := r.variable(entry, fn); ok {
+				vars = append(vars, v)
+			}
+		}
+	}
+	r.vars[fn.offset] = vars
+	return vars
+}
+
+// variable decodes a variable entry. Inlined variables carry their name & type on their abstract origin.
+func (r *Resolver) variable(entry *dwarf.Entry, fn subprogram) (variable, bool) {
+	named := entry
+	if off, ok := entry.Val(dwarf.AttrAbstractOrigin).(dwarf.Offset); ok {
+		origin, found := r.origins[off]
+		if !found {
+			rd := r.data.Reader()
+			rd.Seek(off)
+			origin, _ = rd.Next()
+			r.origins[off] = origin
+		}
+		if origin == nil {
+			return variable{}, false
+		}
+		named = origin
+	}
+
+	name, _ := named.Val(dwarf.AttrName).(string)
+	typeOff, ok := named.Val(dwarf.AttrType).(dwarf.Offset)
+	if name == "" || !ok || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "~") {
+		return variable{}, false // compiler temporaries & results have no name worth reporting
+	}
+	typ, err := r.data.Type(typeOff)
+	if err != nil {
+		return variable{}, false
+	}
+
+	v := variable{name: name, typ: typ}
+	field := entry.AttrField(dwarf.AttrLocation)
+	if field == nil {
+		return variable{}, false
+	}
+	switch field.Class {
+	case dwarf.ClassExprLoc:
+		expr, _ := field.Val.([]byte)
+		if pieces, ok := decodeExpr(expr); ok {
+			v.locs = []location{{low: fn.low, high: fn.high, pieces: pieces}}
+		}
+	case dwarf.ClassLocListPtr:
+		off, _ := field.Val.(int64)
+		v.locs = r.locationList(uint64(off), fn.cu)
+	}
+	return v, len(v.locs) > 0
+}
+
+// covers returns true if the location applies at the supplied address
+func (l location) covers(pc uint64) bool {
+	return pc >= l.low && pc < l.high
+}
+
+// underlying strips typedefs
+func underlying(t dwarf.Type) dwarf.Type {
+	for {
+		td, ok := t.(*dwarf.TypedefType)
+		if !ok {
+			return t
+		}
+		t = td.Type
+	}
+}
+
+// fieldPath resolves an offset within a value of the supplied type to the field (or array element) holding the
+// first word of a string, e.g. ".Server.Addr", returning the name of the struct holding the innermost field. An
+// offset of 0 into a string (or any value that is not a struct or array) is the value itself, so the path is empty.
+func fieldPath(t dwarf.Type, off int64) (string, string, bool) {
+	switch t := underlying(t).(type) {
+	case *dwarf.StructType:
+		if t.StructName == "string" {
+			return "", "", off == 0
+		}
+		for _, f := range t.Field {
+			if off < f.ByteOffset || off >= f.ByteOffset+f.Type.Size() {
+				continue
+			}
+			path, owner, ok := fieldPath(f.Type, off-f.ByteOffset)
+			if !ok {
+				return "", "", false
+			}
+			if owner == "" {
+				owner = t.StructName
+			}
+			return "." + f.Name + path, owner, true
+		}
+		return "", "", false
+	case *dwarf.ArrayType:
+		size := t.Type.Size()
+		if size <= 0 {
+			return "", "", false
+		}
+		path, owner, ok := fieldPath(t.Type, off%size)
+		return fmt.Sprintf("[%d]%s", off/size, path), owner, ok
+	default:
+		return "", "", off == 0
+	}
+}
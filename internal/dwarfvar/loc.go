@@ -0,0 +1,266 @@
+package dwarfvar
+
+import (
+	"encoding/binary"
+)
+
+// DWARF expression operations of interest
+const (
+	opPlusUconst   = 0x23
+	opReg0         = 0x50
+	opReg31        = 0x6f
+	opRegx         = 0x90
+	opFbreg        = 0x91
+	opPiece        = 0x93
+	opCallFrameCFA = 0x9c
+)
+
+// DWARF 5 location list entry kinds
+const (
+	lleEndOfList       = 0x00
+	lleBaseAddressx    = 0x01
+	lleStartxEndx      = 0x02
+	lleStartxLength    = 0x03
+	lleOffsetPair      = 0x04
+	lleDefaultLocation = 0x05
+	lleBaseAddress     = 0x06
+	lleStartEnd        = 0x07
+	lleStartLength     = 0x08
+)
+
+// decodeExpr decodes a location expression into the pieces of a variable. Frame base relative offsets are taken to
+// be relative to the CFA, since that is the frame base the Go compiler uses. Expressions computing anything more
+// involved are not supported.
+func decodeExpr(expr []byte) ([]piece, bool) {
+	var (
+		pieces []piece
+		cur    = piece{reg: -1}
+		set    bool
+		rd     = &locReader{data: expr}
+	)
+	for rd.more() {
+		switch op := rd.byte(); {
+		case op >= opReg0 && op <= opReg31:
+			cur, set = piece{reg: int(op - opReg0)}, true
+		case op == opRegx:
+			cur, set = piece{reg: int(rd.uvarint())}, true
+		case op == opFbreg:
+			cur, set = piece{reg: -1, stack: true, offset: rd.sleb128()}, true
+		case op == opCallFrameCFA:
+			cur, set = piece{reg: -1, stack: true}, true
+		case op == opPlusUconst && cur.stack:
+			cur.offset += int64(rd.uvarint())
+		case op == opPiece:
+			// a piece without a location is optimised out
+			cur.size = int64(rd.uvarint())
+			pieces = append(pieces, cur)
+			cur, set = piece{reg: -1}, false
+		default:
+			// e.g. DW_OP_addr, for package variables, which are not of interest
+			return nil, false
+		}
+	}
+	if rd.failed {
+		return nil, false
+	}
+	if set {
+		pieces = append(pieces, cur)
+	}
+	return pieces, len(pieces) > 0
+}
+
+// locationList decodes the location list at the supplied offset. Entries whose expressions are not supported are
+// omitted.
+func (r *Resolver) locationList(off uint64, cu compileUnit) []location {
+	if off >= uint64(len(r.loc)) {
+		return nil
+	}
+	if r.loclists {
+		return r.locationList5(r.loc[off:], cu)
+	}
+	return r.locationList4(r.loc[off:], cu)
+}
+
+// locationList5 decodes a DWARF 5 location list (.debug_loclists)
+func (r *Resolver) locationList5(data []byte, cu compileUnit) []location {
+	var (
+		locs []location
+		base = cu.base
+		rd   = &locReader{data: data, bo: r.bo}
+	)
+	if cu.addrBase <= uint64(len(r.addr)) {
+		rd.addrs = r.addr[cu.addrBase:]
+	}
+	for rd.more() {
+		var low, high uint64
+		switch rd.byte() {
+		case lleEndOfList:
+			return locs
+		case lleBaseAddressx:
+			base = rd.indexed()
+			continue
+		case lleBaseAddress:
+			base = rd.address()
+			continue
+		case lleStartxEndx:
+			low, high = rd.indexed(), rd.indexed()
+		case lleStartxLength:
+			low = rd.indexed()
+			high = low + rd.uvarint()
+		case lleOffsetPair:
+			low, high = base+rd.uvarint(), base+rd.uvarint()
+		case lleDefaultLocation:
+			low, high = 0, ^uint64(0)
+		case lleStartEnd:
+			low, high = rd.address(), rd.address()
+		case lleStartLength:
+			low = rd.address()
+			high = low + rd.uvarint()
+		default:
+			return locs
+		}
+		expr := rd.bytes(rd.uvarint())
+		if rd.failed {
+			return locs
+		}
+		if pieces, ok := decodeExpr(expr); ok {
+			locs = append(locs, location{low: low, high: high, pieces: pieces})
+		}
+	}
+	return locs
+}
+
+// locReader reads the values making up location lists & expressions. A read beyond the end of the data (or of an
+// address beyond the end of the addresses) marks the reader as failed, after which reads return zero values.
+type locReader struct {
+	data   []byte
+	pos    int
+	bo     binary.ByteOrder
+	addrs  []byte // addresses of the compile unit in .debug_addr, for indexed addresses
+	failed bool
+}
+
+// more returns true if there is data left to read
+func (l *locReader) more() bool {
+	return !l.failed && l.pos < len(l.data)
+}
+
+// fail marks the reader as failed
+func (l *locReader) fail() {
+	l.failed, l.pos = true, len(l.data)
+}
+
+// byte reads a single byte
+func (l *locReader) byte() byte {
+	if !l.more() {
+		l.fail()
+		return 0
+	}
+	l.pos++
+	return l.data[l.pos-1]
+}
+
+// bytes reads the next n bytes
+func (l *locReader) bytes(n uint64) []byte {
+	if l.failed || uint64(len(l.data)-l.pos) < n {
+		l.fail()
+		return nil
+	}
+	l.pos += int(n)
+	return l.data[l.pos-int(n) : l.pos]
+}
+
+// uvarint reads an unsigned LEB128 value
+func (l *locReader) uvarint() uint64 {
+	v, n := binary.Uvarint(l.data[l.pos:])
+	if n <= 0 {
+		l.fail()
+		return 0
+	}
+	l.pos += n
+	return v
+}
+
+// sleb128 reads a signed LEB128 value
+func (l *locReader) sleb128() int64 {
+	v, n := sleb128(l.data[l.pos:])
+	if n <= 0 {
+		l.fail()
+		return 0
+	}
+	l.pos += n
+	return v
+}
+
+// address reads an 8 byte address
+func (l *locReader) address() uint64 {
+	if b := l.bytes(8); b != nil {
+		return l.bo.Uint64(b)
+	}
+	return 0
+}
+
+// indexed reads an index into the addresses of the compile unit, returning the address it refers to
+func (l *locReader) indexed() uint64 {
+	idx := l.uvarint()
+	if l.failed || idx >= uint64(len(l.addrs)/8) {
+		l.fail()
+		return 0
+	}
+	return l.bo.Uint64(l.addrs[idx*8:])
+}
+
+// locationList4 decodes a DWARF 4 location list (.debug_loc): pairs of addresses relative to the base, each followed
+// by the expression. A pair of zeroes ends the list, and a start of all ones selects a new base.
+func (r *Resolver) locationList4(data []byte, cu compileUnit) []location {
+	var (
+		locs []location
+		base = cu.base
+	)
+	for i := 0; i+16 <= len(data); {
+		low, high := r.bo.Uint64(data[i:]), r.bo.Uint64(data[i+8:])
+		i += 16
+		switch {
+		case low == 0 && high == 0:
+			return locs
+		case low == ^uint64(0):
+			base = high
+			continue
+		}
+		if i+2 > len(data) {
+			return locs
+		}
+		size := int(r.bo.Uint16(data[i:]))
+		i += 2
+		if i+size > len(data) {
+			return locs
+		}
+		if pieces, ok := decodeExpr(data[i : i+size]); ok {
+			locs = append(locs, location{low: base + low, high: base + high, pieces: pieces})
+		}
+		i += size
+	}
+	return locs
+}
+
+// sleb128 decodes a signed LEB128 value, returning it along with the number of bytes read (0 if truncated). Unlike
+// binary.Varint, which uses zig-zag encoding, the sign is carried by the top bit of the final byte.
+func sleb128(b []byte) (int64, int) {
+	var (
+		v     int64
+		shift uint
+	)
+	for i, c := range b {
+		if shift < 64 {
+			v |= int64(c&0x7f) << shift
+		}
+		shift += 7
+		if c&0x80 == 0 {
+			if shift < 64 && c&0x40 != 0 {
+				v |= -1 << shift
+			}
+			return v, i + 1
+		}
+	}
+	return 0, 0
+}
@@ -0,0 +1,84 @@
+package dwarfvar
+
+import (
+	"debug/dwarf"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeExpr(t *testing.T) {
+	// DW_OP_reg0 DW_OP_piece 8 DW_OP_fbreg -80 DW_OP_piece 8
+	pieces, ok := decodeExpr([]byte{0x50, 0x93, 0x08, 0x91, 0xb0, 0x7f, 0x93, 0x08})
+	assert.True(t, ok)
+	assert.Equal(t, []piece{
+		{reg: 0, size: 8},
+		{reg: -1, stack: true, offset: -80, size: 8},
+	}, pieces)
+
+	// DW_OP_call_frame_cfa
+	pieces, ok = decodeExpr([]byte{0x9c})
+	assert.True(t, ok)
+	assert.Equal(t, []piece{{reg: -1, stack: true}}, pieces)
+
+	// DW_OP_addr is not supported
+	_, ok = decodeExpr([]byte{0x03, 0, 0, 0, 0, 0, 0, 0, 0})
+	assert.False(t, ok)
+}
+
+func TestLocationList5(t *testing.T) {
+	r := &Resolver{bo: binary.LittleEndian, addr: []byte{0xff, 0xff, 0x10, 0, 0, 0, 0, 0, 0, 0, 0, 0x20, 0, 0, 0, 0, 0, 0}}
+	cu := compileUnit{base: 0x1000, addrBase: 2}
+	data := []byte{
+		lleOffsetPair, 0x10, 0x20, 1, opReg0, // relative to the base of the unit
+		lleBaseAddressx, 1, // 0x2000
+		lleOffsetPair, 0x00, 0x08, 1, opCallFrameCFA,
+		lleStartxLength, 0, 0x04, 1, 0x03, // DW_OP_addr is not supported, so omitted
+		lleStartEnd, 0, 0x30, 0, 0, 0, 0, 0, 0, 0x10, 0x30, 0, 0, 0, 0, 0, 0, 1, opReg0 + 3,
+		lleEndOfList,
+	}
+	assert.Equal(t, []location{
+		{low: 0x1010, high: 0x1020, pieces: []piece{{reg: 0}}},
+		{low: 0x2000, high: 0x2008, pieces: []piece{{reg: -1, stack: true}}},
+		{low: 0x3000, high: 0x3010, pieces: []piece{{reg: 3}}},
+	}, r.locationList5(data, cu))
+
+	// entries are decoded until the data is truncated, or an index is out of range
+	assert.Len(t, r.locationList5(data[:11], cu), 1)
+	assert.Len(t, r.locationList5([]byte{lleStartxEndx, 0, 2, 1, opReg0}, cu), 0)
+}
+
+func TestFieldPath(t *testing.T) {
+	str := &dwarf.StructType{StructName: "string", CommonType: dwarf.CommonType{ByteSize: 16}}
+	config := &dwarf.StructType{
+		StructName: "main.Config",
+		CommonType: dwarf.CommonType{ByteSize: 32},
+		Field: []*dwarf.StructField{
+			{Name: "Name", Type: str, ByteOffset: 0},
+			{Name: "Endpoint", Type: str, ByteOffset: 16},
+		},
+	}
+	server := &dwarf.StructType{
+		StructName: "main.Server",
+		CommonType: dwarf.CommonType{ByteSize: 40},
+		Field: []*dwarf.StructField{
+			{Name: "ID", Type: &dwarf.IntType{BasicType: dwarf.BasicType{CommonType: dwarf.CommonType{ByteSize: 8}}}},
+			{Name: "Opts", Type: config, ByteOffset: 8},
+		},
+	}
+
+	path, owner, ok := fieldPath(server, 24)
+	assert.True(t, ok)
+	assert.Equal(t, ".Opts.Endpoint", path)
+	assert.Equal(t, "main.Config", owner)
+
+	path, owner, ok = fieldPath(str, 0)
+	assert.True(t, ok)
+	assert.Empty(t, path)
+	assert.Empty(t, owner)
+
+	// the length of a string is not a string
+	_, _, ok = fieldPath(server, 32)
+	assert.False(t, ok)
+}
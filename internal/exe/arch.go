@@ -0,0 +1,25 @@
+package exe
+
+// Arch identifies the instruction set architecture an executable was compiled for
+type Arch int
+
+const (
+	// ArchUnknown is used for any architecture that gost does not support
+	ArchUnknown Arch = iota
+	// ArchAMD64 is x86-64
+	ArchAMD64
+	// ArchARM64 is AArch64
+	ArchARM64
+)
+
+// String returns the name of the architecture, as used by GOARCH
+func (a Arch) String() string {
+	switch a {
+	case ArchAMD64:
+		return "amd64"
+	case ArchARM64:
+		return "arm64"
+	default:
+		return "unknown"
+	}
+}
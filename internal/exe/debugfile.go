@@ -0,0 +1,208 @@
+package exe
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// note types of interest
+const (
+	noteGNUBuildID = 3 // NT_GNU_BUILD_ID, in .note.gnu.build-id
+	noteGoBuildID  = 4 // in .note.go.buildid
+)
+
+// debugIDs are the identifiers a separate debug file can be matched against
+type debugIDs struct {
+	gnu  []byte // GNU build ID
+	goID string // Go build ID
+}
+
+// findELFDebugFile locates the separate debug file for an ELF binary within the supplied directory. The following are
+// tried in turn:
+//
+//   - the GNU build ID, at .build-id/xx/yyyy.debug (as laid out under /usr/lib/debug) or xxyyyy.debug
+//   - the file named by .gnu_debuglink, directly within the directory or within .debug, if its CRC matches
+//   - any ELF file in the directory (or .debug) carrying the same GNU or Go build ID
+//
+// nil is returned if no debug file is found.
+func findELFDebugFile(ef *elf.File, dir string) (*elf.File, error) {
+	ids := readDebugIDs(ef)
+	if ids.gnu != nil {
+		id := hex.EncodeToString(ids.gnu)
+		candidates := []string{id + ".debug"}
+		if len(id) > 2 {
+			candidates = append([]string{filepath.Join(".build-id", id[:2], id[2:]+".debug")}, candidates...)
+		}
+		for _, c := range candidates {
+			if df, err := openDebugFile(filepath.Join(dir, c), ids, nil); err != nil || df != nil {
+				return df, err
+			}
+		}
+	}
+
+	if name, crc, ok := readDebugLink(ef); ok {
+		for _, c := range []string{name, filepath.Join(".debug", name)} {
+			if df, err := openDebugFile(filepath.Join(dir, c), debugIDs{}, &crc); err != nil || df != nil {
+				return df, err
+			}
+		}
+	}
+
+	if ids.gnu == nil && ids.goID == "" {
+		return nil, nil // there's nothing to match other files against
+	}
+	for _, d := range []string{dir, filepath.Join(dir, ".debug")} {
+		entries, err := os.ReadDir(d)
+		if errors.Is(err, os.ErrNotExist) && d != dir {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read debug directory: %w", err)
+		}
+		for _, e := range entries {
+			if !e.Type().IsRegular() {
+				continue
+			}
+			if df, err := openDebugFile(filepath.Join(d, e.Name()), ids, nil); err != nil || df != nil {
+				return df, err
+			}
+		}
+	}
+	return nil, nil
+}
+
+// openDebugFile opens a candidate debug file, returning it if it matches the supplied build IDs (or CRC, if
+// supplied). nil is returned if the file does not exist, is not an ELF file or does not match. Build IDs are checked
+// using the headers & notes alone, so that unrelated files are not read in full. A matching file is read into memory,
+// so that nothing is left open.
+func openDebugFile(path string, ids debugIDs, crc *uint32) (*elf.File, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open debug file: %w", err)
+	}
+	defer f.Close()
+
+	magic := make([]byte, len(elfMagic))
+	if _, err := f.ReadAt(magic, 0); err != nil || !bytes.Equal(magic, elfMagic) {
+		return nil, nil
+	}
+	hdr, err := elf.NewFile(f)
+	if err != nil || !hasDebugInfo(hdr) {
+		return nil, nil // e.g. the stripped binary itself, if it resides within the directory
+	}
+	if crc == nil {
+		dids := readDebugIDs(hdr)
+		gnuMatch := ids.gnu != nil && bytes.Equal(ids.gnu, dids.gnu)
+		goMatch := ids.goID != "" && ids.goID == dids.goID
+		if !gnuMatch && !goMatch {
+			return nil, nil
+		}
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read debug file: %w", err)
+	}
+	if crc != nil && crc32.ChecksumIEEE(data) != *crc {
+		return nil, nil
+	}
+	df, err := elf.NewFile(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil
+	}
+	return df, nil
+}
+
+// hasDebugInfo returns true if the file carries a symbol table or DWARF
+func hasDebugInfo(ef *elf.File) bool {
+	for _, s := range ef.Sections {
+		if s.Type == elf.SHT_NOBITS {
+			continue
+		}
+		if s.Name == ".symtab" || strings.HasPrefix(s.Name, ".debug_") || strings.HasPrefix(s.Name, ".zdebug_") {
+			return true
+		}
+	}
+	return false
+}
+
+// readDebugIDs reads the GNU & Go build IDs from their notes. Either may be absent.
+func readDebugIDs(ef *elf.File) debugIDs {
+	var ids debugIDs
+	if desc, ok := readELFNote(ef, ".note.gnu.build-id", "GNU", noteGNUBuildID); ok {
+		ids.gnu = desc
+	}
+	if desc, ok := readELFNote(ef, ".note.go.buildid", "Go", noteGoBuildID); ok {
+		ids.goID = string(desc)
+	}
+	return ids
+}
+
+// readELFNote reads the descriptor of a note from the named section. Notes consist of the name size, descriptor size
+// and type, followed by the name & descriptor, each padded to 4 bytes.
+func readELFNote(ef *elf.File, sectName, name string, typ uint32) ([]byte, bool) {
+	s := ef.Section(sectName)
+	if s == nil || s.Type == elf.SHT_NOBITS {
+		return nil, false
+	}
+	data, err := s.Data()
+	if err != nil {
+		return nil, false
+	}
+	for len(data) >= 12 {
+		nameSize, descSize := uint64(ef.ByteOrder.Uint32(data)), uint64(ef.ByteOrder.Uint32(data[4:]))
+		noteType := ef.ByteOrder.Uint32(data[8:])
+		data = data[12:]
+		nameEnd := align4(nameSize)
+		descEnd := nameEnd + align4(descSize)
+		if descEnd > uint64(len(data)) {
+			return nil, false
+		}
+		noteName := string(bytes.TrimRight(data[:nameSize], "\x00"))
+		if noteName == name && noteType == typ {
+			return data[nameEnd : nameEnd+descSize], true
+		}
+		data = data[descEnd:]
+	}
+	return nil, false
+}
+
+// readDebugLink reads the file name & CRC held in .gnu_debuglink: a null terminated name, padded to 4 bytes, followed
+// by the CRC32 of the debug file
+func readDebugLink(ef *elf.File) (string, uint32, bool) {
+	s := ef.Section(".gnu_debuglink")
+	if s == nil {
+		return "", 0, false
+	}
+	data, err := s.Data()
+	if err != nil {
+		return "", 0, false
+	}
+	end := bytes.IndexByte(data, 0)
+	if end <= 0 {
+		return "", 0, false
+	}
+	off := align4(uint64(end + 1))
+	if off+4 > uint64(len(data)) {
+		return "", 0, false
+	}
+	// the name is used as a path within the debug directory, so must not escape it
+	name := filepath.Base(string(data[:end]))
+	return name, ef.ByteOrder.Uint32(data[off:]), true
+}
+
+// align4 rounds up to a multiple of 4
+func align4(n uint64) uint64 {
+	return (n + 3) &^ 3
+}
@@ -0,0 +1,70 @@
+package exe
+
+import (
+	"bytes"
+	"debug/elf"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nick-jones/gost/internal/testbin"
+)
+
+// minimalProgram is the source of a program that does nothing
+var minimalProgram = map[string]string{"main.go": "package main\n\nfunc main() {}\n"}
+
+func TestFindELFDebugFile(t *testing.T) {
+	// a binary that is not stripped stands in for its own debug file, since it carries symbols & the same build IDs
+	bin := testbin.Build(t, minimalProgram)
+	dir := filepath.Dir(bin)
+
+	ef, err := elf.Open(bin)
+	require.NoError(t, err)
+	defer ef.Close()
+
+	debugDir := filepath.Join(dir, "debug")
+	require.NoError(t, os.Mkdir(debugDir, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(debugDir, "unrelated.debug"), []byte("not an ELF file"), 0o600))
+
+	df, err := findELFDebugFile(ef, debugDir)
+	require.NoError(t, err)
+	assert.Nil(t, df)
+
+	data, err := os.ReadFile(bin)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(debugDir, "renamed.debug"), data, 0o600))
+
+	df, err = findELFDebugFile(ef, debugDir)
+	require.NoError(t, err)
+	require.NotNil(t, df)
+	assert.NotEmpty(t, readDebugIDs(df).goID)
+	assert.Equal(t, readDebugIDs(ef), readDebugIDs(df))
+}
+
+func TestNew_DebugDirWarning(t *testing.T) {
+	bin := testbin.Build(t, minimalProgram)
+	f, err := os.Open(bin)
+	require.NoError(t, err)
+	defer f.Close()
+	dir := filepath.Dir(bin)
+
+	debugDir := filepath.Join(dir, "debug")
+	require.NoError(t, os.Mkdir(debugDir, 0o700))
+
+	warnings := new(bytes.Buffer)
+	_, err = New(f, WithDebugDir(debugDir), WithWarnings(warnings))
+	require.NoError(t, err)
+	assert.Contains(t, warnings.String(), "no debug file matching the binary was found")
+
+	data, err := os.ReadFile(bin)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(debugDir, "app.debug"), data, 0o600))
+
+	warnings.Reset()
+	_, err = New(f, WithDebugDir(debugDir), WithWarnings(warnings))
+	require.NoError(t, err)
+	assert.Empty(t, warnings.String())
+}
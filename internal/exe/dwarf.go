@@ -0,0 +1,154 @@
+package exe
+
+import (
+	"bytes"
+	"compress/zlib"
+	"debug/dwarf"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// LineTable maps addresses to source positions, as recorded in the DWARF line table (.debug_line)
+type LineTable struct {
+	rows []lineRow
+}
+
+// lineRow is a single row of the line table. Each applies from its address up to that of the following row.
+type lineRow struct {
+	addr uint64
+	file string
+	line int
+	end  bool // end of a sequence, i.e. the address following the final instruction it covers
+}
+
+// DWARFLineTable returns the line table decoded from the DWARF debugging information. This is omitted from binaries
+// linked with -w (or -s), in which case an error is returned. The result is cached, since decoding is relatively
+// expensive.
+func (e *File) DWARFLineTable() (*LineTable, error) {
+	e.linesOnce.Do(func() {
+		e.lines, e.linesErr = e.newDWARFLineTable()
+	})
+	return e.lines, e.linesErr
+}
+
+// newDWARFLineTable decodes the line programs of every compilation unit
+func (e *File) newDWARFLineTable() (*LineTable, error) {
+	data, err := e.DWARF()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DWARF: %w", err)
+	}
+
+	tab := &LineTable{}
+	r := data.Reader()
+	for {
+		entry, err := r.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read DWARF entry: %w", err)
+		}
+		if entry == nil {
+			break
+		}
+		if entry.Tag != dwarf.TagCompileUnit {
+			r.SkipChildren()
+			continue
+		}
+		lr, err := data.LineReader(entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read line program: %w", err)
+		}
+		r.SkipChildren()
+		if lr == nil {
+			continue // no line program for this unit
+		}
+
+		var le dwarf.LineEntry
+		for {
+			if err := lr.Next(&le); errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("failed to read line entry: %w", err)
+			}
+			row := lineRow{addr: le.Address, line: le.Line, end: le.EndSequence}
+			if le.File != nil {
+				row.file = le.File.Name
+			}
+			tab.rows = append(tab.rows, row)
+		}
+	}
+	if len(tab.rows) == 0 {
+		return nil, errors.New("DWARF line table is empty")
+	}
+
+	// sequences may abut, in which case the end of one must sort before the start of the next
+	sort.SliceStable(tab.rows, func(i, j int) bool {
+		if tab.rows[i].addr != tab.rows[j].addr {
+			return tab.rows[i].addr < tab.rows[j].addr
+		}
+		return tab.rows[i].end && !tab.rows[j].end
+	})
+	return tab, nil
+}
+
+// PCToLine returns the file & line of the instruction at the supplied address. The final return value indicates
+// whether the address is covered by the table.
+func (t *LineTable) PCToLine(pc uint64) (string, int, bool) {
+	i := sort.Search(len(t.rows), func(i int) bool {
+		return t.rows[i].addr > pc
+	}) - 1
+	if i < 0 || t.rows[i].end || t.rows[i].line == 0 {
+		return "", 0, false
+	}
+	return t.rows[i].file, t.rows[i].line, true
+}
+
+// DWARF returns the DWARF debugging information. This is omitted from binaries linked with -w (or -s).
+func (e *File) DWARF() (*dwarf.Data, error) {
+	return e.adapt.DWARF()
+}
+
+// DWARFSection returns the contents of a DWARF section, named without its prefix (e.g. "loclists" for
+// .debug_loclists). This is for sections the dwarf package does not expose, such as location lists. Compressed
+// sections are decompressed.
+func (e *File) DWARFSection(name string) ([]byte, error) {
+	return e.adapt.DWARFSection(name)
+}
+
+// machoSectionNameLen is the maximum length of a Mach-O section name; longer DWARF section names are truncated
+const machoSectionNameLen = 16
+
+// matchDWARFSection determines whether a section holds the named DWARF section, either uncompressed (with the supplied
+// prefix) or compressed (with the z prefix). Names truncated by the Mach-O length limit are also matched.
+func matchDWARFSection(sectName, name, prefix, zprefix string) (compressed bool, ok bool) {
+	for _, p := range []string{zprefix, prefix} {
+		if !strings.HasPrefix(sectName, p) {
+			continue
+		}
+		suffix := sectName[len(p):]
+		if suffix == name || len(sectName) == machoSectionNameLen && strings.HasPrefix(name, suffix) {
+			return p == zprefix, true
+		}
+	}
+	return false, false
+}
+
+// decompressZDebug decompresses the contents of a .zdebug_* section: "ZLIB", followed by the uncompressed size as a
+// big endian uint64, followed by a zlib stream
+func decompressZDebug(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "ZLIB" {
+		return nil, errors.New("invalid compressed DWARF section")
+	}
+	// the recorded size is not trusted for allocation, since the file may be malformed
+	zr, err := zlib.NewReader(bytes.NewReader(data[12:]))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, zr); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
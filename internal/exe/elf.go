@@ -1,6 +1,7 @@
 package exe
 
 import (
+	"debug/dwarf"
 	"debug/elf"
 	"encoding/binary"
 	"errors"
@@ -13,26 +14,46 @@ import (
 // elfFile covers Executable and Linkable Format (elfFile) type binaries
 type elfFile struct {
 	byteOrder binary.ByteOrder
+	arch      Arch
 	symbols   []Symbol
 	sections  []Section
+	file      *elf.File
+	debug     *elf.File // separate debug file, holding symbols & DWARF (if located)
 }
 
-// newELFFile initialises the elfFile type
-func newELFFile(r io.ReaderAt) (*elfFile, error) {
+// newELFFile initialises the elfFile type. If a debug directory is supplied, a separate debug file matching the binary
+// is searched for within it; symbols & DWARF are read from it where found.
+func newELFFile(r io.ReaderAt, debugDir string) (*elfFile, error) {
 	ef, err := elf.NewFile(r)
 	if err != nil {
 		return nil, err
 	}
 
+	var df *elf.File
+	if debugDir != "" {
+		if df, err = findELFDebugFile(ef, debugDir); err != nil {
+			return nil, err
+		}
+	}
+
 	syms, err := mapELFSymbols(ef)
 	if err != nil {
 		return nil, err
 	}
+	if len(syms) == 0 && df != nil {
+		// the binary has been stripped, so symbols are taken from the debug file, which shares its addresses
+		if syms, err = mapELFSymbols(df); err != nil {
+			return nil, err
+		}
+	}
 
 	return &elfFile{
 		byteOrder: ef.ByteOrder,
+		arch:      mapELFArch(ef.Machine),
 		symbols:   syms,
 		sections:  mapELFSections(ef),
+		file:      ef,
+		debug:     df,
 	}, nil
 }
 
@@ -41,6 +62,11 @@ func (e *elfFile) ByteOrder() binary.ByteOrder {
 	return e.byteOrder
 }
 
+// Arch returns the instruction set architecture
+func (e *elfFile) Arch() Arch {
+	return e.arch
+}
+
 // TextSection locates and returns .text
 func (e *elfFile) TextSection() (Section, error) {
 	return e.section(".text")
@@ -76,6 +102,50 @@ func (e *elfFile) Symbols() ([]Symbol, error) {
 	return e.symbols, nil
 }
 
+// DWARF decodes the debugging information from the .debug_* sections, decompressing them if necessary
+func (e *elfFile) DWARF() (*dwarf.Data, error) {
+	return e.dwarfFile().DWARF()
+}
+
+// DWARFSection returns the decompressed contents of a DWARF section, named without its prefix (e.g. "loclists")
+func (e *elfFile) DWARFSection(name string) ([]byte, error) {
+	for _, s := range e.dwarfFile().Sections {
+		compressed, ok := matchDWARFSection(s.Name, name, ".debug_", ".zdebug_")
+		if !ok {
+			continue
+		}
+		data, err := s.Data()
+		if err != nil {
+			return nil, err
+		}
+		if compressed {
+			return decompressZDebug(data)
+		}
+		return data, nil
+	}
+	return nil, ErrSectionNotFound
+}
+
+// dwarfFile returns the file holding DWARF: the debug file, if located, otherwise the binary
+func (e *elfFile) dwarfFile() *elf.File {
+	if e.debug != nil {
+		return e.debug
+	}
+	return e.file
+}
+
+// mapELFArch maps the ELF machine type to our standard type
+func mapELFArch(m elf.Machine) Arch {
+	switch m {
+	case elf.EM_X86_64:
+		return ArchAMD64
+	case elf.EM_AARCH64:
+		return ArchARM64
+	default:
+		return ArchUnknown
+	}
+}
+
 // mapELFSymbols maps ELF symbols to our standard type
 func mapELFSymbols(f *elf.File) ([]Symbol, error) {
 	// read symbols
@@ -141,17 +211,23 @@ func mapELFSymbols(f *elf.File) ([]Symbol, error) {
 	return mapped, nil
 }
 
-// mapELFSections maps ELF sections to our standard type
+// mapELFSections maps ELF sections to our standard type. Dynamic relocations are applied to the contents of loaded
+// sections, so that pointers within position-independent binaries can be read.
 func mapELFSections(f *elf.File) []Section {
+	relocs := readELFRelocations(f)
 	sects := make([]Section, len(f.Sections))
 	for i, s := range f.Sections {
+		var r io.ReaderAt = s.ReaderAt
+		if s.ReaderAt != nil && s.Flags&elf.SHF_ALLOC != 0 {
+			r = newRelocatingReaderAt(s.ReaderAt, s.Addr, s.Size, relocs, f.ByteOrder)
+		}
 		sects[i] = Section{
 			Name: s.Name,
 			AddrRange: address.Range{
 				Start: s.Addr,
 				End:   s.Addr + s.Size,
 			},
-			ReaderAt: s.ReaderAt,
+			ReaderAt: r,
 		}
 	}
 	return sects
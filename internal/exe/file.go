@@ -2,11 +2,15 @@ package exe
 
 import (
 	"bytes"
+	"debug/buildinfo"
+	"debug/dwarf"
+	"debug/gosym"
 	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
 	"sort"
+	"sync"
 
 	"github.com/nick-jones/gost/internal/address"
 )
@@ -20,24 +24,79 @@ var (
 	machoMagicLE = []byte{0xcf, 0xfa, 0xed, 0xfe}
 	machoMagicBE = []byte{0xfe, 0xed, 0xfa, 0xcf}
 	elfMagic     = []byte{0x7f, 0x45, 0x4c, 0x46}
+	peMagic      = []byte{0x4d, 0x5a}
+	peSignature  = []byte{'P', 'E', 0, 0}
 )
 
+// peHeaderOffsetAt is the offset of e_lfanew within the MS-DOS header, which holds the offset of the PE signature
+const peHeaderOffsetAt = 0x3c
+
 // File represents an executable file
 type File struct {
+	r     io.ReaderAt
 	adapt adapter
+
+	pclnOnce sync.Once
+	pcln     *gosym.Table
+	pclnErr  error
+
+	linesOnce sync.Once
+	lines     *LineTable
+	linesErr  error
 }
 
 type adapter interface {
 	ByteOrder() binary.ByteOrder
+	Arch() Arch
 	TextSection() (Section, error)
 	RODataSection() (Section, error)
 	PCLNTabSection() (Section, error)
 	Sections() ([]Section, error)
 	Symbols() ([]Symbol, error)
+	DWARF() (*dwarf.Data, error)
+	DWARFSection(name string) ([]byte, error)
+}
+
+// Option configures how a File is opened
+type Option func(*options)
+
+// options holds the settings applied by Option values
+type options struct {
+	debugDir string
+	warnings io.Writer
+}
+
+// warn reports a non-fatal problem, if a writer was supplied for warnings
+func (o *options) warn(format string, args ...interface{}) {
+	if o.warnings != nil {
+		fmt.Fprintf(o.warnings, "warning: "+format+"\n", args...)
+	}
+}
+
+// WithDebugDir supplies a directory holding separate debug files, as produced by objcopy --only-keep-debug. Where the
+// binary is a stripped ELF binary, the matching debug file supplies symbols & DWARF, while code & data are still read
+// from the binary.
+func WithDebugDir(dir string) Option {
+	return func(o *options) {
+		o.debugDir = dir
+	}
+}
+
+// WithWarnings supplies a writer for non-fatal problems, such as a debug directory holding no debug file matching the
+// binary
+func WithWarnings(w io.Writer) Option {
+	return func(o *options) {
+		o.warnings = w
+	}
 }
 
 // New creates a new File instance
-func New(r io.ReaderAt) (*File, error) {
+func New(r io.ReaderAt, opts ...Option) (*File, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	ident := make([]byte, 4)
 	if _, err := r.ReadAt(ident, 0); err != nil {
 		return nil, err
@@ -51,15 +110,38 @@ func New(r io.ReaderAt) (*File, error) {
 	case bytes.Equal(ident, machoMagicLE) || bytes.Equal(ident, machoMagicBE):
 		adapt, err = newMachoFile(r)
 	case bytes.Equal(ident, elfMagic):
-		adapt, err = newELFFile(r)
+		adapt, err = newELFFile(r, o.debugDir)
+	case bytes.Equal(ident[:2], peMagic) && hasPESignature(r):
+		adapt, err = newPEFile(r)
 	default:
 		err = errors.New("could not determine exe type")
 	}
 	if err != nil {
 		return nil, err
 	}
+	if o.debugDir != "" {
+		if ef, ok := adapt.(*elfFile); !ok {
+			o.warn("separate debug files are only supported for ELF binaries, ignoring %s", o.debugDir)
+		} else if ef.debug == nil {
+			o.warn("no debug file matching the binary was found in %s", o.debugDir)
+		}
+	}
+
+	return &File{r: r, adapt: adapt}, nil
+}
 
-	return &File{adapt: adapt}, nil
+// hasPESignature returns true if the PE signature is found where the MS-DOS header says, since MZ alone is shared
+// with plain MS-DOS executables
+func hasPESignature(r io.ReaderAt) bool {
+	offset := make([]byte, 4)
+	if _, err := r.ReadAt(offset, peHeaderOffsetAt); err != nil {
+		return false
+	}
+	sig := make([]byte, len(peSignature))
+	if _, err := r.ReadAt(sig, int64(binary.LittleEndian.Uint32(offset))); err != nil {
+		return false
+	}
+	return bytes.Equal(sig, peSignature)
 }
 
 // ByteOrder returns the byte order (little or big endian)
@@ -67,6 +149,16 @@ func (e *File) ByteOrder() binary.ByteOrder {
 	return e.adapt.ByteOrder()
 }
 
+// Arch returns the instruction set architecture the executable was compiled for
+func (e *File) Arch() Arch {
+	return e.adapt.Arch()
+}
+
+// Sections returns all sections
+func (e *File) Sections() ([]Section, error) {
+	return e.adapt.Sections()
+}
+
 // SectionContainingRange returns the section that fully contains the supplied range
 func (e *File) SectionContainingRange(addrRange address.Range) (Section, error) {
 	sects, err := e.adapt.Sections()
@@ -81,6 +173,31 @@ func (e *File) SectionContainingRange(addrRange address.Range) (Section, error)
 	return Section{}, fmt.Errorf("failed to locate section for address range %s", addrRange)
 }
 
+// LoadedSections returns the data of the sections loaded into memory that are accepted by the filter
+func (e *File) LoadedSections(filter func(Section) bool) ([]SectionData, error) {
+	sects, err := e.adapt.Sections()
+	if err != nil {
+		return nil, err
+	}
+	loaded := make([]SectionData, 0)
+	for _, s := range sects {
+		if s.AddrRange.Start == 0 || !filter(s) {
+			continue // not loaded into memory (e.g. debug information), or not wanted
+		}
+		data, err := s.Data()
+		if err != nil || len(data) == 0 {
+			continue // e.g. sections without file data, such as .bss
+		}
+		loaded = append(loaded, SectionData{Section: s, Bytes: data})
+	}
+	return loaded, nil
+}
+
+// Symbols returns every symbol in the symbol table, ordered by address. Stripped binaries have none.
+func (e *File) Symbols() ([]Symbol, error) {
+	return e.adapt.Symbols()
+}
+
 // Symbol locates a symbol by name
 func (e *File) Symbol(name string) (Symbol, error) {
 	sects, err := e.adapt.Symbols()
@@ -95,8 +212,23 @@ func (e *File) Symbol(name string) (Symbol, error) {
 	return Symbol{}, ErrSymbolNotFound
 }
 
+// SymbolAt locates a symbol starting at the supplied address
+func (e *File) SymbolAt(addr uint64) (Symbol, error) {
+	syms, err := e.adapt.Symbols()
+	if err != nil {
+		return Symbol{}, err
+	}
+	for _, s := range syms {
+		if s.AddrRange.Start == addr {
+			return s, nil
+		}
+	}
+	return Symbol{}, ErrSymbolNotFound
+}
+
 // SymbolsForAddresses locates at most one symbol for each address. Not every address may resolve to a symbol; in such
-// cases the address will not feature in the returned map.
+// cases the address will not feature in the returned map. Addresses absent from the symbol table (e.g. because the
+// binary is stripped) are resolved against the functions listed in the PCLN table.
 func (e *File) SymbolsForAddresses(addrs []uint64) (map[uint64]Symbol, error) {
 	sort.Slice(addrs, func(i, j int) bool {
 		return addrs[i] < addrs[j]
@@ -119,9 +251,36 @@ func (e *File) SymbolsForAddresses(addrs []uint64) (map[uint64]Symbol, error) {
 		}
 		results[addr] = syms[i]
 	}
+
+	if len(results) < len(addrs) {
+		e.resolveFunctions(addrs, results)
+	}
 	return results, nil
 }
 
+// resolveFunctions populates results for any unresolved addresses that fall within a function known to the PCLN table
+func (e *File) resolveFunctions(addrs []uint64, results map[uint64]Symbol) {
+	tab, err := e.PCLNTable()
+	if err != nil {
+		// the symbol table remains authoritative, so an unusable PCLN table just means fewer results
+		return
+	}
+	for _, addr := range addrs {
+		if _, found := results[addr]; found {
+			continue
+		}
+		if fn := tab.PCToFunc(addr); fn != nil {
+			results[addr] = Symbol{
+				Name: fn.Name,
+				AddrRange: address.Range{
+					Start: fn.Entry,
+					End:   fn.End,
+				},
+			}
+		}
+	}
+}
+
 // TextSection returns the text section
 func (e *File) TextSection() (Section, error) {
 	return e.adapt.TextSection()
@@ -132,6 +291,11 @@ func (e *File) RODataSection() (Section, error) {
 	return e.adapt.RODataSection()
 }
 
+// BuildInfo returns the build information embedded by the Go linker (toolchain version, modules & build settings)
+func (e *File) BuildInfo() (*buildinfo.BuildInfo, error) {
+	return buildinfo.Read(e.r)
+}
+
 // PCLNTabSection returns the Go PCLN table section
 func (e *File) PCLNTabSection() (Section, error) {
 	return e.adapt.PCLNTabSection()
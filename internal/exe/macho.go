@@ -1,6 +1,7 @@
 package exe
 
 import (
+	"debug/dwarf"
 	"debug/macho"
 	"encoding/binary"
 	"io"
@@ -12,8 +13,10 @@ import (
 // machoFile covers Mach-O type executables
 type machoFile struct {
 	byteOrder binary.ByteOrder
+	arch      Arch
 	symbols   []Symbol
 	sections  []Section
+	file      *macho.File
 }
 
 // newMachoFile initialises the machoFile type
@@ -25,8 +28,10 @@ func newMachoFile(r io.ReaderAt) (*machoFile, error) {
 
 	return &machoFile{
 		byteOrder: mf.ByteOrder,
+		arch:      mapMachoArch(mf.Cpu),
 		symbols:   mapMachoSymbols(mf),
 		sections:  mapMachoSections(mf),
+		file:      mf,
 	}, nil
 }
 
@@ -35,6 +40,11 @@ func (m *machoFile) ByteOrder() binary.ByteOrder {
 	return m.byteOrder
 }
 
+// Arch returns the instruction set architecture
+func (m *machoFile) Arch() Arch {
+	return m.arch
+}
+
 // TextSection locates and returns __text
 func (m *machoFile) TextSection() (Section, error) {
 	return m.section("__text")
@@ -70,6 +80,42 @@ func (m *machoFile) Symbols() ([]Symbol, error) {
 	return m.symbols, nil
 }
 
+// DWARF decodes the debugging information from the __DWARF segment, decompressing sections if necessary
+func (m *machoFile) DWARF() (*dwarf.Data, error) {
+	return m.file.DWARF()
+}
+
+// DWARFSection returns the decompressed contents of a DWARF section, named without its prefix (e.g. "loclists")
+func (m *machoFile) DWARFSection(name string) ([]byte, error) {
+	for _, s := range m.file.Sections {
+		compressed, ok := matchDWARFSection(s.Name, name, "__debug_", "__zdebug_")
+		if !ok {
+			continue
+		}
+		data, err := s.Data()
+		if err != nil {
+			return nil, err
+		}
+		if compressed {
+			return decompressZDebug(data)
+		}
+		return data, nil
+	}
+	return nil, ErrSectionNotFound
+}
+
+// mapMachoArch maps the Mach-O CPU type to our standard type
+func mapMachoArch(cpu macho.Cpu) Arch {
+	switch cpu {
+	case macho.CpuAmd64:
+		return ArchAMD64
+	case macho.CpuArm64:
+		return ArchARM64
+	default:
+		return ArchUnknown
+	}
+}
+
 // mapMachoSymbols maps Mach-O symbols to our standard type
 func mapMachoSymbols(f *macho.File) []Symbol {
 	if f.Symtab == nil {
@@ -0,0 +1,66 @@
+package exe
+
+import (
+	"debug/gosym"
+
+	"github.com/nick-jones/gost/internal/address"
+)
+
+// PCLNTable returns the Go symbol table decoded from the PCLN table. This carries function, file and line information
+// and is present even in stripped binaries. The result is cached, since decoding is relatively expensive.
+func (e *File) PCLNTable() (*gosym.Table, error) {
+	e.pclnOnce.Do(func() {
+		e.pcln, e.pclnErr = e.newPCLNTable()
+	})
+	return e.pcln, e.pclnErr
+}
+
+// newPCLNTable decodes the PCLN table
+func (e *File) newPCLNTable() (*gosym.Table, error) {
+	txt, err := e.TextSection()
+	if err != nil {
+		return nil, err
+	}
+
+	pclntab, err := e.PCLNTabSection()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := pclntab.Data()
+	if err != nil {
+		return nil, err
+	}
+
+	// Function entries are offsets from the start of Go code, which is usually the start of the text section. Where
+	// the binary was linked externally (e.g. with cgo, or as a PIE on some platforms), C runtime code may precede it,
+	// so runtime.text is preferred where symbols are present.
+	text := txt.AddrRange.Start
+	if sym, err := e.Symbol("runtime.text"); err == nil {
+		text = sym.AddrRange.Start
+	}
+
+	// `gosym.LineTable` doesn't provide file information. So we have to wrap it with `gosym.Table`, which does. Not
+	// need to provide symtab data - and in fact, the symtab section is zero size in Mach-O binaries, so I'm assuming
+	// it is no longer populated.
+	return gosym.NewTable(nil, gosym.NewLineTable(data, text))
+}
+
+// Functions returns the functions listed in the PCLN table, ordered by address
+func (e *File) Functions() ([]Symbol, error) {
+	tab, err := e.PCLNTable()
+	if err != nil {
+		return nil, err
+	}
+	funcs := make([]Symbol, len(tab.Funcs))
+	for i, fn := range tab.Funcs {
+		funcs[i] = Symbol{
+			Name: fn.Name,
+			AddrRange: address.Range{
+				Start: fn.Entry,
+				End:   fn.End,
+			},
+		}
+	}
+	return funcs, nil
+}
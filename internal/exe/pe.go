@@ -0,0 +1,321 @@
+package exe
+
+import (
+	"debug/dwarf"
+	"debug/pe"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sort"
+
+	"github.com/nick-jones/gost/internal/address"
+)
+
+// peFile covers Portable Executable (PE) type binaries, as used by Windows
+type peFile struct {
+	byteOrder binary.ByteOrder
+	arch      Arch
+	symbols   []Symbol
+	sections  []Section
+	file      *pe.File
+}
+
+// newPEFile initialises the peFile type
+func newPEFile(r io.ReaderAt) (*peFile, error) {
+	pf, err := pe.NewFile(r)
+	if err != nil {
+		return nil, err
+	}
+
+	imageBase, err := peImageBase(pf)
+	if err != nil {
+		return nil, err
+	}
+
+	return &peFile{
+		byteOrder: binary.LittleEndian, // PE is always little endian
+		arch:      mapPEArch(pf.Machine),
+		symbols:   mapPESymbols(pf, imageBase),
+		sections:  mapPESections(pf, imageBase),
+		file:      pf,
+	}, nil
+}
+
+// ByteOrder returns the byte order (little or big endian)
+func (p *peFile) ByteOrder() binary.ByteOrder {
+	return p.byteOrder
+}
+
+// Arch returns the instruction set architecture
+func (p *peFile) Arch() Arch {
+	return p.arch
+}
+
+// TextSection locates and returns .text
+func (p *peFile) TextSection() (Section, error) {
+	return p.section(".text")
+}
+
+// RODataSection locates and returns .rdata
+func (p *peFile) RODataSection() (Section, error) {
+	return p.section(".rdata")
+}
+
+// PCLNTabSection returns the region of .rdata bounded by the runtime.pclntab and runtime.epclntab symbols. Unlike ELF
+// and Mach-O, the Go linker does not emit a dedicated section for the PCLN table in PE binaries. If the symbols have
+// been stripped, .rdata is searched for the table header instead, and the region extends to the end of the section.
+func (p *peFile) PCLNTabSection() (Section, error) {
+	rdata, err := p.section(".rdata")
+	if err != nil {
+		return Section{}, err
+	}
+
+	start, end, err := p.symbolBounds("runtime.pclntab", "runtime.epclntab")
+	if errors.Is(err, ErrSectionNotFound) {
+		start, err = findPCLNTabHeader(rdata)
+		end = rdata.AddrRange.End
+	}
+	if err != nil {
+		return Section{}, err
+	}
+	if !rdata.AddrRange.Contains(start) || !rdata.AddrRange.Contains(end) {
+		return Section{}, ErrSectionNotFound
+	}
+
+	return Section{
+		Name: "runtime.pclntab",
+		AddrRange: address.Range{
+			Start: start,
+			End:   end,
+		},
+		ReaderAt: io.NewSectionReader(rdata, int64(start-rdata.AddrRange.Start), int64(end-start)),
+	}, nil
+}
+
+// symbolBounds returns the addresses of a pair of symbols marking the start and end of a region
+func (p *peFile) symbolBounds(startName, endName string) (uint64, uint64, error) {
+	var start, end *Symbol
+	for i, s := range p.symbols {
+		switch s.Name {
+		case startName:
+			start = &p.symbols[i]
+		case endName:
+			end = &p.symbols[i]
+		}
+	}
+	if start == nil || end == nil || end.AddrRange.Start < start.AddrRange.Start {
+		return 0, 0, ErrSectionNotFound
+	}
+	return start.AddrRange.Start, end.AddrRange.Start, nil
+}
+
+// pclntabMagics are the magic numbers that open the PCLN table header, one for each revision of its format
+var pclntabMagics = map[uint32]bool{
+	0xfffffffb: true, // Go 1.2
+	0xfffffffa: true, // Go 1.16
+	0xfffffff0: true, // Go 1.18
+	0xfffffff1: true, // Go 1.20
+}
+
+// findPCLNTabHeader searches the section for the PCLN table header, returning its address. The header is the magic
+// number followed by 2 zero bytes, the instruction size quantum and the pointer size.
+func findPCLNTabHeader(sect Section) (uint64, error) {
+	data, err := sect.Data()
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i+8 <= len(data); i += 4 {
+		if !pclntabMagics[binary.LittleEndian.Uint32(data[i:])] || data[i+4] != 0 || data[i+5] != 0 {
+			continue
+		}
+		if quantum := data[i+6]; quantum != 1 && quantum != 2 && quantum != 4 {
+			continue
+		}
+		if ptrSize := data[i+7]; ptrSize != 4 && ptrSize != 8 {
+			continue
+		}
+		return sect.AddrRange.Start + uint64(i), nil
+	}
+	return 0, ErrSectionNotFound
+}
+
+// section searches for a section by name
+func (p *peFile) section(name string) (Section, error) {
+	for _, s := range p.sections {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+	return Section{}, ErrSectionNotFound
+}
+
+// Sections returns all sections
+func (p *peFile) Sections() ([]Section, error) {
+	return p.sections, nil
+}
+
+// Symbols returns all symbols
+func (p *peFile) Symbols() ([]Symbol, error) {
+	return p.symbols, nil
+}
+
+// DWARF decodes the debugging information from the .debug_* sections, decompressing them if necessary
+func (p *peFile) DWARF() (*dwarf.Data, error) {
+	return p.file.DWARF()
+}
+
+// DWARFSection returns the decompressed contents of a DWARF section, named without its prefix (e.g. "loclists")
+func (p *peFile) DWARFSection(name string) ([]byte, error) {
+	for _, s := range p.file.Sections {
+		compressed, ok := matchDWARFSection(s.Name, name, ".debug_", ".zdebug_")
+		if !ok {
+			continue
+		}
+		data, err := s.Data()
+		if err != nil {
+			return nil, err
+		}
+		if 0 < s.VirtualSize && s.VirtualSize < s.Size {
+			data = data[:s.VirtualSize] // the remainder is padding to the file alignment
+		}
+		if compressed {
+			return decompressZDebug(data)
+		}
+		return data, nil
+	}
+	return nil, ErrSectionNotFound
+}
+
+// peImageBase returns the preferred load address of the image, which all section addresses are relative to
+func peImageBase(f *pe.File) (uint64, error) {
+	switch oh := f.OptionalHeader.(type) {
+	case *pe.OptionalHeader64:
+		return oh.ImageBase, nil
+	case *pe.OptionalHeader32:
+		return uint64(oh.ImageBase), nil
+	default:
+		return 0, errors.New("missing PE optional header")
+	}
+}
+
+// mapPEArch maps the PE machine type to our standard type
+func mapPEArch(machine uint16) Arch {
+	switch machine {
+	case pe.IMAGE_FILE_MACHINE_AMD64:
+		return ArchAMD64
+	case pe.IMAGE_FILE_MACHINE_ARM64:
+		return ArchARM64
+	default:
+		return ArchUnknown
+	}
+}
+
+// mapPESymbols maps COFF symbols to our standard type
+func mapPESymbols(f *pe.File, imageBase uint64) []Symbol {
+	// COFF symbol values are relative to the section they belong to, so they are translated into virtual addresses
+	// here. Symbols without a section (absolute, debug, undefined) are of no use to us.
+	syms := make([]Symbol, 0, len(f.Symbols))
+	for _, s := range f.Symbols {
+		if s.SectionNumber <= 0 || int(s.SectionNumber) > len(f.Sections) {
+			continue
+		}
+		addr := imageBase + uint64(f.Sections[s.SectionNumber-1].VirtualAddress) + uint64(s.Value)
+		syms = append(syms, Symbol{
+			Name: s.Name,
+			AddrRange: address.Range{
+				Start: addr,
+				End:   addr,
+			},
+		})
+	}
+	sort.SliceStable(syms, func(i, j int) bool {
+		return syms[i].AddrRange.Start < syms[j].AddrRange.Start
+	})
+
+	// COFF symbols do not carry size, so the following performs the same best guess as Mach-O: a symbol is assumed
+	// to extend up to the next symbol with a greater address.
+	mapped := make([]Symbol, 0, len(syms))
+	buffered := make([]Symbol, 0)
+	var anchor uint64
+	for _, s := range syms {
+		if len(buffered) > 0 && s.AddrRange.Start > anchor {
+			for _, b := range buffered {
+				b.AddrRange.End = s.AddrRange.Start - 1
+				mapped = append(mapped, b)
+			}
+			buffered = buffered[:0]
+		}
+		buffered = append(buffered, s)
+		anchor = s.AddrRange.Start
+	}
+	// since we don't know where to end the remaining symbols, they keep the same start & end address
+	return append(mapped, buffered...)
+}
+
+// mapPESections maps PE sections to our standard type
+func mapPESections(f *pe.File, imageBase uint64) []Section {
+	sects := make([]Section, len(f.Sections))
+	for i, s := range f.Sections {
+		start := imageBase + uint64(s.VirtualAddress)
+		sects[i] = Section{
+			Name: s.Name,
+			AddrRange: address.Range{
+				Start: start,
+				End:   start + uint64(s.VirtualSize),
+			},
+			ReaderAt: newZeroFillReaderAt(s),
+		}
+	}
+	return sects
+}
+
+// zeroFillReaderAt wraps a section reader so that reads beyond the raw data (but within the virtual size of the
+// section) return zeroes. PE sections may be larger in memory than on disk, with the loader zeroing the remainder.
+// Reads beyond the virtual size fail with io.EOF, as they would for any other section.
+type zeroFillReaderAt struct {
+	io.ReaderAt
+	size        int64 // size of the raw data
+	virtualSize int64 // size in memory
+}
+
+// newZeroFillReaderAt wraps the reader of a PE section. Sections without a virtual size (as found in object files)
+// are sized by their raw data.
+func newZeroFillReaderAt(s *pe.Section) *zeroFillReaderAt {
+	z := &zeroFillReaderAt{ReaderAt: s.ReaderAt, size: int64(s.Size), virtualSize: int64(s.VirtualSize)}
+	if z.virtualSize == 0 {
+		z.virtualSize = z.size
+	}
+	return z
+}
+
+// ReadAt reads from the underlying reader, zero filling anything that sits past the raw data
+func (z *zeroFillReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	if off >= z.virtualSize {
+		return 0, io.EOF
+	}
+	var eof error
+	if remaining := z.virtualSize - off; int64(len(p)) > remaining {
+		p, eof = p[:remaining], io.EOF
+	}
+
+	raw := p[:0]
+	if off < z.size {
+		raw = p
+		if avail := z.size - off; int64(len(raw)) > avail {
+			raw = raw[:avail]
+		}
+	}
+	if len(raw) > 0 {
+		n, err := z.ReaderAt.ReadAt(raw, off)
+		if err != nil && (!errors.Is(err, io.EOF) || n < len(raw)) {
+			return n, err
+		}
+	}
+	for i := len(raw); i < len(p); i++ {
+		p[i] = 0
+	}
+	return len(p), eof
+}
@@ -0,0 +1,47 @@
+package exe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestZeroFillReaderAt_ReadAt(t *testing.T) {
+	z := &zeroFillReaderAt{ReaderAt: bytes.NewReader([]byte{1, 2, 3, 4}), size: 4, virtualSize: 6}
+
+	// spanning the raw data & the zero filled remainder
+	p := make([]byte, 4)
+	n, err := z.ReadAt(p, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 4, n)
+	assert.Equal(t, []byte{3, 4, 0, 0}, p)
+
+	// running past the virtual size
+	p = []byte{9, 9, 9, 9}
+	n, err = z.ReadAt(p, 4)
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []byte{0, 0}, p[:n])
+
+	// entirely beyond the virtual size
+	n, err = z.ReadAt(p, 6)
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, 0, n)
+}
+
+func TestHasPESignature(t *testing.T) {
+	data := make([]byte, 0x48)
+	copy(data, peMagic)
+	binary.LittleEndian.PutUint32(data[peHeaderOffsetAt:], 0x40)
+	assert.False(t, hasPESignature(bytes.NewReader(data)), "MS-DOS executable")
+
+	copy(data[0x40:], peSignature)
+	assert.True(t, hasPESignature(bytes.NewReader(data)))
+
+	binary.LittleEndian.PutUint32(data[peHeaderOffsetAt:], 0x1000)
+	assert.False(t, hasPESignature(bytes.NewReader(data)), "signature offset out of bounds")
+}
@@ -0,0 +1,106 @@
+package exe
+
+import (
+	"debug/elf"
+	"encoding/binary"
+	"io"
+	"sort"
+)
+
+// relaEntrySize is the size of an Elf64_Rela entry: offset, info (symbol & type) and addend
+const relaEntrySize = 24
+
+// relocation is a pointer sized word that is written by the dynamic loader
+type relocation struct {
+	addr  uint64
+	value uint64
+}
+
+// readELFRelocations reads the relative dynamic relocations of a position-independent binary (-buildmode=pie or
+// c-shared). These set pointers within data to the load address plus an addend, so with the binary considered to be
+// loaded at its link address, the value is simply the addend. Some linkers also write the value into the file, but
+// this is not guaranteed (e.g. lld without -z apply-dynamic-relocs leaves zeroes). Relocations against symbols refer
+// to other objects, so cannot be resolved. The result is ordered by address.
+func readELFRelocations(ef *elf.File) []relocation {
+	var relative uint32
+	switch ef.Machine {
+	case elf.EM_X86_64:
+		relative = uint32(elf.R_X86_64_RELATIVE)
+	case elf.EM_AARCH64:
+		relative = uint32(elf.R_AARCH64_RELATIVE)
+	default:
+		return nil
+	}
+	if ef.Class != elf.ELFCLASS64 {
+		return nil
+	}
+
+	var relocs []relocation
+	for _, s := range ef.Sections {
+		// dynamic relocations are loaded, unlike those retained for the static linker (e.g. with -ldflags -q)
+		if s.Type != elf.SHT_RELA || s.Flags&elf.SHF_ALLOC == 0 {
+			continue
+		}
+		data, err := s.Data()
+		if err != nil {
+			continue
+		}
+		for i := 0; i+relaEntrySize <= len(data); i += relaEntrySize {
+			if elf.R_TYPE64(ef.ByteOrder.Uint64(data[i+8:])) != relative {
+				continue
+			}
+			relocs = append(relocs, relocation{
+				addr:  ef.ByteOrder.Uint64(data[i:]),
+				value: ef.ByteOrder.Uint64(data[i+16:]),
+			})
+		}
+	}
+	sort.Slice(relocs, func(i, j int) bool {
+		return relocs[i].addr < relocs[j].addr
+	})
+	return relocs
+}
+
+// relocatingReaderAt wraps a section reader so that the words written by relocations hold their values, as they would
+// once loaded
+type relocatingReaderAt struct {
+	io.ReaderAt
+	addr      uint64       // address of the section
+	relocs    []relocation // relocations within the section, ordered by address
+	byteOrder binary.ByteOrder
+}
+
+// newRelocatingReaderAt wraps the reader of a section starting at the supplied address, if any relocations fall
+// within it
+func newRelocatingReaderAt(r io.ReaderAt, addr, size uint64, relocs []relocation, bo binary.ByteOrder) io.ReaderAt {
+	start := sort.Search(len(relocs), func(i int) bool {
+		return relocs[i].addr+8 > addr
+	})
+	end := sort.Search(len(relocs), func(i int) bool {
+		return relocs[i].addr >= addr+size
+	})
+	if start >= end {
+		return r
+	}
+	return &relocatingReaderAt{ReaderAt: r, addr: addr, relocs: relocs[start:end], byteOrder: bo}
+}
+
+// ReadAt reads from the underlying reader, then overwrites any relocated words (or parts thereof) that were read
+func (r *relocatingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := r.ReaderAt.ReadAt(p, off)
+	lo, hi := r.addr+uint64(off), r.addr+uint64(off)+uint64(n)
+	i := sort.Search(len(r.relocs), func(i int) bool {
+		return r.relocs[i].addr+8 > lo
+	})
+	word := make([]byte, 8)
+	for ; i < len(r.relocs) && r.relocs[i].addr < hi; i++ {
+		rel := r.relocs[i]
+		r.byteOrder.PutUint64(word, rel.value)
+		for j := uint64(0); j < 8; j++ {
+			if a := rel.addr + j; a >= lo && a < hi {
+				p[a-lo] = word[j]
+			}
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,39 @@
+package exe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelocatingReaderAt(t *testing.T) {
+	// a section at 0x1000 holding two zeroed pointers, the second of which straddles the end of the section
+	data := make([]byte, 20)
+	relocs := []relocation{
+		{addr: 0xff8, value: 0x1111}, // precedes the section
+		{addr: 0x1008, value: 0x2222},
+		{addr: 0x1010, value: 0x3333},
+		{addr: 0x1020, value: 0x4444}, // follows the section
+	}
+	r := newRelocatingReaderAt(bytes.NewReader(data), 0x1000, uint64(len(data)), relocs, binary.LittleEndian)
+
+	buf := make([]byte, len(data))
+	_, err := r.ReadAt(buf, 0)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), binary.LittleEndian.Uint64(buf))
+	assert.Equal(t, uint64(0x2222), binary.LittleEndian.Uint64(buf[8:]))
+	assert.Equal(t, []byte{0x33, 0x33, 0, 0}, buf[16:])
+
+	// reads starting part way through a relocated word
+	buf = make([]byte, 4)
+	_, err = r.ReadAt(buf, 9)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x22, 0, 0, 0}, buf)
+
+	// sections without relocations are left as they are
+	plain := bytes.NewReader(data)
+	assert.Same(t, plain, newRelocatingReaderAt(plain, 0x2000, 0x100, relocs, binary.LittleEndian))
+}
@@ -1,6 +1,7 @@
 package exe
 
 import (
+	"fmt"
 	"io"
 
 	"github.com/nick-jones/gost/internal/address"
@@ -19,9 +20,28 @@ func (s Section) Data() ([]byte, error) {
 	if size == 0 {
 		return nil, nil
 	}
+	if s.ReaderAt == nil {
+		// e.g. compressed debug sections, which the ELF package does not expose a reader for
+		return nil, fmt.Errorf("section %s cannot be read", s.Name)
+	}
 	buf := make([]byte, size)
 	if _, err := s.ReaderAt.ReadAt(buf, 0); err != nil {
 		return nil, err
 	}
 	return buf, nil
 }
+
+// SectionData is a section along with the bytes it holds
+type SectionData struct {
+	Section
+	Bytes []byte
+}
+
+// At returns the n bytes held at the supplied address, or false if they are not all within the section
+func (s SectionData) At(addr, n uint64) ([]byte, bool) {
+	if addr < s.AddrRange.Start || addr-s.AddrRange.Start+n > uint64(len(s.Bytes)) {
+		return nil, false
+	}
+	off := addr - s.AddrRange.Start
+	return s.Bytes[off : off+n], true
+}
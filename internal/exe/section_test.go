@@ -0,0 +1,22 @@
+package exe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nick-jones/gost/internal/address"
+)
+
+func TestSectionData_At(t *testing.T) {
+	s := SectionData{Section: Section{AddrRange: address.Range{Start: 0x1000, End: 0x1004}}, Bytes: []byte{1, 2, 3, 4}}
+
+	b, ok := s.At(0x1001, 2)
+	assert.True(t, ok)
+	assert.Equal(t, []byte{2, 3}, b)
+
+	_, ok = s.At(0x1002, 4) // running past the end
+	assert.False(t, ok)
+	_, ok = s.At(0xfff, 1) // before the start
+	assert.False(t, ok)
+}
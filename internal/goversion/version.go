@@ -0,0 +1,70 @@
+// Package goversion identifies the version of the Go toolchain that compiled a binary. Symbol naming and data layout
+// change between releases, so analysis needs to know what to expect.
+package goversion
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/nick-jones/gost/internal/exe"
+)
+
+// Version is a Go release, ignoring patch level
+type Version struct {
+	Major int
+	Minor int
+}
+
+var versionRegexp = regexp.MustCompile(`go(\d+)\.(\d+)`)
+
+// PCLN table magic numbers. Each is introduced by a release that changed the table layout, so they provide a lower
+// bound of the version when build information is unavailable.
+var pclnMagics = map[uint32]Version{
+	0xfffffffb: {Major: 1, Minor: 2},
+	0xfffffffa: {Major: 1, Minor: 16},
+	0xfffffff0: {Major: 1, Minor: 18},
+	0xfffffff1: {Major: 1, Minor: 20},
+}
+
+// Parse parses a version string as reported by `go version`, e.g. "go1.21.3", "go1.22rc1" or "devel go1.23-abcdef"
+func Parse(s string) (Version, error) {
+	m := versionRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return Version{}, fmt.Errorf("invalid go version: %q", s)
+	}
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+	return Version{Major: major, Minor: minor}, nil
+}
+
+// Detect determines the version of the toolchain that compiled the executable. The build information embedded by the
+// linker is used where possible, falling back to the PCLN table header.
+func Detect(f *exe.File) (Version, error) {
+	if bi, err := f.BuildInfo(); err == nil {
+		return Parse(bi.GoVersion)
+	}
+
+	sect, err := f.PCLNTabSection()
+	if err != nil {
+		return Version{}, fmt.Errorf("failed to locate pclntab: %w", err)
+	}
+	buf := make([]byte, 4)
+	if _, err := sect.ReadAt(buf, 0); err != nil {
+		return Version{}, fmt.Errorf("failed to read pclntab header: %w", err)
+	}
+	if v, found := pclnMagics[f.ByteOrder().Uint32(buf)]; found {
+		return v, nil
+	}
+	return Version{}, fmt.Errorf("unrecognised pclntab magic %x", buf)
+}
+
+// AtLeast returns true if the version is the same or newer than the supplied version
+func (v Version) AtLeast(major, minor int) bool {
+	return v.Major > major || v.Major == major && v.Minor >= minor
+}
+
+// String returns the version in the same style as `go version`
+func (v Version) String() string {
+	return fmt.Sprintf("go%d.%d", v.Major, v.Minor)
+}
@@ -0,0 +1,65 @@
+package goversion_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nick-jones/gost/internal/goversion"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name   string
+		input  string
+		expect goversion.Version
+		err    bool
+	}{
+		{
+			name:   "release",
+			input:  "go1.21.3",
+			expect: goversion.Version{Major: 1, Minor: 21},
+		},
+		{
+			name:   "without patch",
+			input:  "go1.16",
+			expect: goversion.Version{Major: 1, Minor: 16},
+		},
+		{
+			name:   "release candidate",
+			input:  "go1.22rc1",
+			expect: goversion.Version{Major: 1, Minor: 22},
+		},
+		{
+			name:   "development build",
+			input:  "devel go1.23-abcdef Mon Jan 1 00:00:00 2024 +0000",
+			expect: goversion.Version{Major: 1, Minor: 23},
+		},
+		{
+			name:  "invalid",
+			input: "banana",
+			err:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v, err := goversion.Parse(tc.input)
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expect, v)
+		})
+	}
+}
+
+func TestVersion_AtLeast(t *testing.T) {
+	v := goversion.Version{Major: 1, Minor: 20}
+	assert.True(t, v.AtLeast(1, 19))
+	assert.True(t, v.AtLeast(1, 20))
+	assert.False(t, v.AtLeast(1, 21))
+	assert.False(t, v.AtLeast(2, 0))
+	assert.Equal(t, "go1.20", v.String())
+}
@@ -0,0 +1,348 @@
+// Package inline decodes the inline trees recorded in the PCLN table, which describe the calls that were inlined into
+// each function. The file & line of an instruction within inlined code refer to the inlined function, so without the
+// tree it is attributed to the function it was inlined into, with no record of the call that led there.
+//
+// For every function, the PCDATA_InlTreeIndex table maps instructions to an entry of the function's inline tree
+// (FUNCDATA_InlTree). Each entry names the inlined function, and carries the offset of an instruction whose position
+// is the call site, which in turn maps to the entry of the caller (if that was itself inlined).
+//
+// The stack frame size of each function is also decoded from the same tables (PCDATA for the stack pointer delta).
+package inline
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/nick-jones/gost/internal/address"
+	"github.com/nick-jones/gost/internal/exe"
+	"github.com/nick-jones/gost/internal/goversion"
+	"github.com/nick-jones/gost/internal/moduledata"
+)
+
+// ErrUnsupported is returned for PCLN table formats that are not decoded (those predating Go 1.16)
+var ErrUnsupported = errors.New("unsupported pclntab format")
+
+const (
+	pcdataInlTreeIndex = 2
+	funcdataInlTree    = 3
+)
+
+// format describes the parts of the PCLN table layout that vary between releases
+type format struct {
+	textStart   bool // whether the header carries textStart (1.18 & 1.19), shifting the fields that follow
+	entryOffset bool // whether functions record their entry as a 32-bit offset from the start of text (1.18+)
+	funcSize    int  // size of the fixed part of _func, which is followed by the pcdata & funcdata offsets
+	inlSize     int  // size of each inline tree entry (runtime.inlinedCall)
+	inlNameOff  int  // offset of the name within each inline tree entry
+	inlParentPC int  // offset of the parent pc within each inline tree entry
+}
+
+// formats are keyed by the magic number at the start of the PCLN table
+var formats = map[uint32]format{
+	// Go 1.16 & 1.17
+	0xfffffffa: {funcSize: 44, inlSize: 20, inlNameOff: 12, inlParentPC: 16},
+	// Go 1.18 & 1.19
+	0xfffffff0: {textStart: true, entryOffset: true, funcSize: 40, inlSize: 20, inlNameOff: 12, inlParentPC: 16},
+	// Go 1.20 onwards, which adds startLine to both _func & inlinedCall (dropping the call site, which is derived from
+	// the parent pc anyway). Later releases leave textStart in the header, but unpopulated.
+	0xfffffff1: {textStart: true, entryOffset: true, funcSize: 44, inlSize: 16, inlNameOff: 4, inlParentPC: 8},
+}
+
+// Frame is a single function in an inline stack
+type Frame struct {
+	Function string
+	File     string
+	Line     int
+}
+
+// Table decodes inline stacks
+type Table struct {
+	f      *exe.File
+	bo     binary.ByteOrder
+	format format
+
+	ptrSize     int
+	minLC       uint64
+	text        uint64 // start of text, which function entries are relative to (1.18+)
+	gofunc      uint64 // base of funcdata offsets (1.18+)
+	funcnametab []byte
+	pctab       []byte
+	functab     []byte
+	nfunc       int
+
+	lineFor func(pc uint64) (string, int)
+}
+
+// New prepares to decode the inline trees of the executable. The positions of frames are resolved with the supplied
+// function, which returns the file & line of an instruction.
+func New(f *exe.File, lineFor func(pc uint64) (string, int)) (*Table, error) {
+	sect, err := f.PCLNTabSection()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate pclntab: %w", err)
+	}
+	data, err := sect.Data()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pclntab: %w", err)
+	}
+	if len(data) < 8 {
+		return nil, ErrUnsupported
+	}
+
+	bo := f.ByteOrder()
+	fm, ok := formats[bo.Uint32(data)]
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	t := &Table{
+		f:       f,
+		lineFor: lineFor,
+		bo:      bo,
+		format:  fm,
+		minLC:   uint64(data[6]),
+		ptrSize: int(data[7]),
+	}
+	if t.ptrSize != 8 {
+		return nil, ErrUnsupported
+	}
+
+	// header words follow the magic, pads, minLC & ptrSize: nfunc, nfiles, [textStart], funcnameOffset, cuOffset,
+	// filetabOffset, pctabOffset, pclnOffset
+	word := func(i int) uint64 {
+		off := 8 + i*t.ptrSize
+		if off+t.ptrSize > len(data) {
+			return 0
+		}
+		return bo.Uint64(data[off:])
+	}
+	first := 2
+	if fm.textStart {
+		first = 3
+	}
+	t.nfunc = int(word(0))
+	funcnameOff, pctabOff, pclnOff := word(first), word(first+3), word(first+4)
+	if funcnameOff >= uint64(len(data)) || pctabOff >= uint64(len(data)) || pclnOff >= uint64(len(data)) {
+		return nil, fmt.Errorf("invalid pclntab header")
+	}
+	t.funcnametab, t.pctab, t.functab = data[funcnameOff:], data[pctabOff:], data[pclnOff:]
+
+	if fm.entryOffset {
+		// funcdata is relative to go:func.*, whose address is only recorded in the module data
+		v, err := goversion.Detect(f)
+		if err != nil {
+			return nil, err
+		}
+		md, err := moduledata.Find(f, v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate module data: %w", err)
+		}
+		t.text, t.gofunc = md.Text, md.GoFunc
+	}
+	return t, nil
+}
+
+// Stack returns the inline stack at the supplied address, innermost first. The final frame is the function the code
+// physically resides in. Nil is returned if the address does not fall within a known function.
+func (t *Table) Stack(pc uint64) []Frame {
+	fn, ok := t.findFunc(pc)
+	if !ok {
+		return nil
+	}
+
+	file, line := t.lineFor(pc)
+	frames := make([]Frame, 0, 1)
+	ix := t.pcvalue(fn, fn.pcdata(pcdataInlTreeIndex), pc)
+	for depth := 0; ix >= 0 && depth < 100; depth++ {
+		name, parentPC, ok := t.inlinedCall(fn, ix)
+		if !ok {
+			break
+		}
+		frames = append(frames, Frame{Function: name, File: file, Line: line})
+		// the parent pc is an instruction whose position is that of the call site
+		pc = fn.entry + uint64(parentPC)
+		file, line = t.lineFor(pc)
+		ix = t.pcvalue(fn, fn.pcdata(pcdataInlTreeIndex), pc)
+	}
+	return append(frames, Frame{Function: fn.name, File: file, Line: line})
+}
+
+// SPDelta returns the size of the stack frame at the supplied address, i.e. the distance from the stack pointer to
+// the caller's stack pointer (excluding any return address pushed by the call). This relates stack pointer relative
+// addresses to the canonical frame address, which DWARF locates stack variables from.
+func (t *Table) SPDelta(pc uint64) (int64, bool) {
+	fn, ok := t.findFunc(pc)
+	if !ok {
+		return 0, false
+	}
+	// pcsp is the first of the pc tables, following the entry, name, args & deferreturn
+	off := 20
+	if t.format.entryOffset {
+		off = 16
+	}
+	delta := t.pcvalue(fn, t.bo.Uint32(fn.raw[off:]), pc)
+	if delta < 0 {
+		return 0, false
+	}
+	return int64(delta), true
+}
+
+// function is a decoded _func
+type function struct {
+	entry    uint64
+	name     string
+	raw      []byte // the _func, followed by the pcdata & funcdata offsets
+	npcdata  int
+	nfuncdat int
+	t        *Table
+}
+
+// pcdata returns the pctab offset of a pcdata table, or 0 if absent
+func (fn function) pcdata(table int) uint32 {
+	if table >= fn.npcdata {
+		return 0
+	}
+	off := fn.t.format.funcSize + table*4
+	if off+4 > len(fn.raw) {
+		return 0
+	}
+	return fn.t.bo.Uint32(fn.raw[off:])
+}
+
+// funcdata returns the address of a funcdata item, or 0 if absent
+func (fn function) funcdata(item int) uint64 {
+	if item >= fn.nfuncdat {
+		return 0
+	}
+	if fn.t.format.entryOffset {
+		off := fn.t.format.funcSize + fn.npcdata*4 + item*4
+		if off+4 > len(fn.raw) {
+			return 0
+		}
+		v := fn.t.bo.Uint32(fn.raw[off:])
+		if v == ^uint32(0) {
+			return 0
+		}
+		return fn.t.gofunc + uint64(v)
+	}
+	// prior to 1.18, funcdata are pointers, aligned following the pcdata offsets
+	off := fn.t.format.funcSize + fn.npcdata*4
+	off = (off + fn.t.ptrSize - 1) &^ (fn.t.ptrSize - 1)
+	off += item * fn.t.ptrSize
+	if off+fn.t.ptrSize > len(fn.raw) {
+		return 0
+	}
+	return fn.t.bo.Uint64(fn.raw[off:])
+}
+
+// findFunc locates the function containing the address, by binary search of the function table
+func (t *Table) findFunc(pc uint64) (function, bool) {
+	entrySize := 2 * t.ptrSize
+	if t.format.entryOffset {
+		entrySize = 8
+	}
+	if len(t.functab) < (t.nfunc+1)*entrySize {
+		return function{}, false
+	}
+	entryAt := func(i int) (uint64, uint64) {
+		e := t.functab[i*entrySize:]
+		if t.format.entryOffset {
+			return t.text + uint64(t.bo.Uint32(e)), uint64(t.bo.Uint32(e[4:]))
+		}
+		return t.bo.Uint64(e), t.bo.Uint64(e[t.ptrSize:])
+	}
+
+	// the table is terminated by an entry marking the end of the final function
+	if first, _ := entryAt(0); pc < first {
+		return function{}, false
+	}
+	if end, _ := entryAt(t.nfunc); pc >= end {
+		return function{}, false
+	}
+	i := sort.Search(t.nfunc, func(i int) bool {
+		entry, _ := entryAt(i)
+		return entry > pc
+	}) - 1
+	entry, funcOff := entryAt(i)
+	if funcOff+uint64(t.format.funcSize) > uint64(len(t.functab)) {
+		return function{}, false
+	}
+	raw := t.functab[funcOff:]
+
+	// the name offset follows the entry, and the counts close the fixed part of the structure
+	nameOff := 8
+	npcdataOff := 32
+	if t.format.entryOffset {
+		nameOff, npcdataOff = 4, 28
+	}
+	return function{
+		entry:    entry,
+		name:     t.funcName(t.bo.Uint32(raw[nameOff:])),
+		raw:      raw,
+		npcdata:  int(t.bo.Uint32(raw[npcdataOff:])),
+		nfuncdat: int(raw[t.format.funcSize-1]),
+		t:        t,
+	}, true
+}
+
+// funcName reads a null terminated name from the function name table
+func (t *Table) funcName(off uint32) string {
+	if uint64(off) >= uint64(len(t.funcnametab)) {
+		return ""
+	}
+	name := t.funcnametab[off:]
+	for i, b := range name {
+		if b == 0 {
+			return string(name[:i])
+		}
+	}
+	return ""
+}
+
+// pcvalue decodes a pcdata table, returning the value at the supplied address. -1 is returned if the table is absent
+// or does not cover the address.
+func (t *Table) pcvalue(fn function, off uint32, target uint64) int32 {
+	if off == 0 || uint64(off) >= uint64(len(t.pctab)) {
+		return -1
+	}
+	p := t.pctab[off:]
+	pc, val := fn.entry, int32(-1)
+	for first := true; ; first = false {
+		uvdelta, n := binary.Uvarint(p)
+		if n <= 0 || uvdelta == 0 && !first {
+			return -1
+		}
+		p = p[n:]
+		val += int32(-(uint32(uvdelta) & 1) ^ (uint32(uvdelta) >> 1))
+		pcdelta, n := binary.Uvarint(p)
+		if n <= 0 {
+			return -1
+		}
+		p = p[n:]
+		pc += pcdelta * t.minLC
+		if target < pc {
+			return val
+		}
+	}
+}
+
+// inlinedCall reads an entry of the function's inline tree, returning the name of the inlined function and the offset
+// (from the function entry) of an instruction positioned at the call site
+func (t *Table) inlinedCall(fn function, ix int32) (string, int32, bool) {
+	tree := fn.funcdata(funcdataInlTree)
+	if tree == 0 {
+		return "", 0, false
+	}
+	addr := tree + uint64(ix)*uint64(t.format.inlSize)
+	sect, err := t.f.SectionContainingRange(address.Range{Start: addr, End: addr + uint64(t.format.inlSize)})
+	if err != nil {
+		return "", 0, false
+	}
+	buf := make([]byte, t.format.inlSize)
+	if _, err := sect.ReadAt(buf, int64(addr-sect.AddrRange.Start)); err != nil {
+		return "", 0, false
+	}
+	name := t.funcName(t.bo.Uint32(buf[t.format.inlNameOff:]))
+	parentPC := int32(t.bo.Uint32(buf[t.format.inlParentPC:]))
+	return name, parentPC, true
+}
@@ -0,0 +1,185 @@
+// Package moduledata locates and decodes runtime.firstmoduledata. The linker populates this structure with the
+// boundaries of each region of the binary, and it remains intact when symbols are stripped.
+package moduledata
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/nick-jones/gost/internal/address"
+	"github.com/nick-jones/gost/internal/exe"
+	"github.com/nick-jones/gost/internal/goversion"
+)
+
+// ErrNotFound is returned when the module data cannot be located
+var ErrNotFound = errors.New("module data not found")
+
+// ModuleData carries the fields of runtime.moduledata that describe the layout of the binary. Fields that are absent
+// from the layout used by the compiling toolchain are left as zero.
+type ModuleData struct {
+	Addr       uint64 // address of the structure itself
+	PCHeader   uint64
+	Text       uint64
+	EText      uint64
+	NoPtrData  uint64
+	ENoPtrData uint64
+	Data       uint64
+	EData      uint64
+	BSS        uint64
+	EBSS       uint64
+	NoPtrBSS   uint64
+	ENoPtrBSS  uint64
+	End        uint64
+	GCData     uint64
+	GCBSS      uint64
+	Types      uint64
+	ETypes     uint64
+	ROData     uint64 // Go 1.18+
+	GoFunc     uint64 // Go 1.18+
+}
+
+// layout describes the word index of fields following the fixed prefix of the structure. The prefix (pcHeader, the
+// pclntab slices, findfunctab, minpc, maxpc and the text & data boundaries) has not changed since Go 1.16.
+type layout struct {
+	end, gcdata, gcbss int
+	types, etypes      int
+	rodata, gofunc     int // -1 where absent
+}
+
+var (
+	// layoutGo116 covers Go 1.16 & 1.17
+	layoutGo116 = layout{end: 32, gcdata: 33, gcbss: 34, types: 35, etypes: 36, rodata: -1, gofunc: -1}
+	// layoutGo118 covers Go 1.18 & 1.19, which add rodata and gofunc
+	layoutGo118 = layout{end: 32, gcdata: 33, gcbss: 34, types: 35, etypes: 36, rodata: 37, gofunc: 38}
+	// layoutGo120 covers Go 1.20 onwards, which add coverage counter boundaries ahead of end
+	layoutGo120 = layout{end: 34, gcdata: 35, gcbss: 36, types: 37, etypes: 38, rodata: 39, gofunc: 40}
+	// layoutTypeDesc covers later releases, which record the type descriptor & itab sizes alongside the types
+	layoutTypeDesc = layout{end: 34, gcdata: 35, gcbss: 36, types: 37, etypes: 39, rodata: 42, gofunc: 43}
+)
+
+// words is the number of pointer sized words read, which covers the largest layout
+const words = 44
+
+// Find locates runtime.firstmoduledata. The first field of the structure points at the PCLN table header, so data
+// sections are searched for a word holding that address. Each match is decoded according to the layouts plausible for
+// the toolchain version, and the first to produce consistent boundaries is returned.
+func Find(f *exe.File, v goversion.Version) (ModuleData, error) {
+	pclntab, err := f.PCLNTabSection()
+	if err != nil {
+		return ModuleData{}, fmt.Errorf("failed to locate pclntab: %w", err)
+	}
+	txt, err := f.TextSection()
+	if err != nil {
+		return ModuleData{}, fmt.Errorf("failed to locate text: %w", err)
+	}
+
+	sects, err := f.Sections()
+	if err != nil {
+		return ModuleData{}, err
+	}
+
+	bo := f.ByteOrder()
+	for _, sect := range sects {
+		if !isDataSection(sect.Name) {
+			continue
+		}
+		data, err := sect.Data()
+		if err != nil {
+			return ModuleData{}, fmt.Errorf("failed to read %s: %w", sect.Name, err)
+		}
+		for off := 0; off+words*8 <= len(data); off += 8 {
+			if bo.Uint64(data[off:]) != pclntab.AddrRange.Start {
+				continue
+			}
+			w := make([]uint64, words)
+			for i := range w {
+				w[i] = bo.Uint64(data[off+i*8:])
+			}
+			for _, l := range layouts(v) {
+				md := decode(w, l)
+				md.Addr = sect.AddrRange.Start + uint64(off)
+				if md.valid(txt.AddrRange) {
+					return md, nil
+				}
+			}
+		}
+	}
+	return ModuleData{}, ErrNotFound
+}
+
+// layouts returns the layouts to attempt for the supplied version, most likely first. If the version is unknown, all
+// layouts are attempted.
+func layouts(v goversion.Version) []layout {
+	switch {
+	case v.AtLeast(1, 20):
+		return []layout{layoutTypeDesc, layoutGo120}
+	case v.AtLeast(1, 18):
+		return []layout{layoutGo118}
+	case v.AtLeast(1, 16):
+		return []layout{layoutGo116}
+	default:
+		return []layout{layoutTypeDesc, layoutGo120, layoutGo118, layoutGo116}
+	}
+}
+
+// decode maps words to fields according to the layout
+func decode(w []uint64, l layout) ModuleData {
+	md := ModuleData{
+		PCHeader:   w[0],
+		Text:       w[22],
+		EText:      w[23],
+		NoPtrData:  w[24],
+		ENoPtrData: w[25],
+		Data:       w[26],
+		EData:      w[27],
+		BSS:        w[28],
+		EBSS:       w[29],
+		NoPtrBSS:   w[30],
+		ENoPtrBSS:  w[31],
+		End:        w[l.end],
+		GCData:     w[l.gcdata],
+		GCBSS:      w[l.gcbss],
+		Types:      w[l.types],
+		ETypes:     w[l.etypes],
+	}
+	if l.rodata >= 0 {
+		md.ROData = w[l.rodata]
+		md.GoFunc = w[l.gofunc]
+	}
+	return md
+}
+
+// valid performs sanity checks over the decoded boundaries, which is how the correct layout is identified
+func (md ModuleData) valid(text address.Range) bool {
+	ordered := func(vals ...uint64) bool {
+		for i := 1; i < len(vals); i++ {
+			if vals[i] < vals[i-1] {
+				return false
+			}
+		}
+		return true
+	}
+	switch {
+	case !text.Contains(md.Text) || !ordered(md.Text, md.EText):
+		return false
+	case !ordered(md.NoPtrData, md.ENoPtrData) || !ordered(md.Data, md.EData) || !ordered(md.BSS, md.EBSS):
+		return false
+	case !ordered(md.NoPtrBSS, md.ENoPtrBSS) || md.End != md.ENoPtrBSS:
+		return false
+	case md.Types == 0 || !ordered(md.Types, md.ETypes):
+		return false
+	case md.ROData != 0 && (md.GoFunc == 0 || md.ROData > md.ETypes && md.ROData > md.Types):
+		return false
+	default:
+		return true
+	}
+}
+
+// isDataSection returns true for sections that may hold runtime.firstmoduledata. Its placement has varied between
+// releases (.noptrdata, later .go.module), so any writable data section is considered.
+func isDataSection(name string) bool {
+	name = strings.ToLower(name)
+	return strings.Contains(name, "data") && !strings.Contains(name, "rodata") && !strings.Contains(name, "rel.ro") ||
+		strings.Contains(name, "module")
+}
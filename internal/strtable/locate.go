@@ -6,20 +6,86 @@ import (
 
 	"github.com/nick-jones/gost/internal/address"
 	"github.com/nick-jones/gost/internal/exe"
+	"github.com/nick-jones/gost/internal/goversion"
+	"github.com/nick-jones/gost/internal/moduledata"
 )
 
-// Locate returns the address range for the Go string table. This uses symbol information, falling back to plain old
-// guess work if symbols are unavailable.
+// Locate returns the address range for the Go string table. This uses symbol information, falling back to guess work
+// if symbols are unavailable. The symbol name depends on the toolchain version, which is detected from the binary.
+// Guessing first derives the range from the region boundaries recorded in runtime.moduledata, and failing that resorts
+// to plain old ASCII detection.
 func Locate(f *exe.File, guess bool) (address.Range, error) {
-	// use the go.string.* symbol if available
-	sym, err := f.Symbol("go.string.*")
+	// an undetectable version isn't fatal, all known names are tried regardless
+	v, _ := goversion.Detect(f)
+
+	names := symbolNames(v)
+	for _, name := range names {
+		sym, err := f.Symbol(name)
+		if errors.Is(err, exe.ErrSymbolNotFound) {
+			continue
+		}
+		if err != nil {
+			return address.Range{}, fmt.Errorf("failed to locate %s range: %w", name, err)
+		}
+		return boundStringTable(f, sym.AddrRange)
+	}
+
+	if guess {
+		if addrRange, err := moduleDataStringTableAddressRange(f, v); err == nil {
+			return addrRange, nil
+		}
+		return guessStringTableAddressRange(f)
+	}
+	return address.Range{}, fmt.Errorf("failed to locate %s range: %w", names[0], exe.ErrSymbolNotFound)
+}
+
+// symbolNames returns the names the string table symbol may carry, most likely first. Go 1.20 renamed go.string.* to
+// go:string.* (along with other linker generated symbols), so the version decides which is tried first.
+func symbolNames(v goversion.Version) []string {
+	if v.AtLeast(1, 20) {
+		return []string{"go:string.*", "go.string.*"}
+	}
+	return []string{"go.string.*", "go:string.*"}
+}
+
+// boundStringTable ensures the string table range has an end. Since Go 1.20 the ELF symbol carries no size, and if no
+// other symbol follows it (so the size can't be inferred) the end of the containing section is used.
+func boundStringTable(f *exe.File, addrRange address.Range) (address.Range, error) {
+	if addrRange.Size() > 0 {
+		return addrRange, nil
+	}
+	sect, err := f.SectionContainingRange(addrRange)
 	if err != nil {
-		if errors.Is(err, exe.ErrSymbolNotFound) && guess {
-			return guessStringTableAddressRange(f)
+		return address.Range{}, err
+	}
+	return address.Range{Start: addrRange.Start, End: sect.AddrRange.End}, nil
+}
+
+// moduleDataStringTableAddressRange derives the string table range from runtime.moduledata, which survives stripping.
+// The linker lays out read-only data as types (prior to their move to a dedicated section), then strings, then
+// function metadata and GC programs, so the table runs from the end of the types up to the next known boundary.
+func moduleDataStringTableAddressRange(f *exe.File, v goversion.Version) (address.Range, error) {
+	md, err := moduledata.Find(f, v)
+	if err != nil {
+		return address.Range{}, err
+	}
+
+	start := md.ROData
+	if start == 0 || start == md.Types {
+		start = md.ETypes
+	}
+	sect, err := f.SectionContainingRange(address.Range{Start: start, End: start})
+	if err != nil {
+		return address.Range{}, err
+	}
+
+	end := sect.AddrRange.End
+	for _, boundary := range []uint64{md.GoFunc, md.GCData, md.GCBSS, md.Types} {
+		if boundary > start && boundary < end {
+			end = boundary
 		}
-		return address.Range{}, fmt.Errorf("failed to locate go.string.* range: %w", err)
 	}
-	return sym.AddrRange, nil
+	return address.Range{Start: start, End: end}, nil
 }
 
 // guessStringTableAddressRange is an imperfect attempt at guessing the address range for the Go string table. It looks
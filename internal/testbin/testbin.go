@@ -0,0 +1,52 @@
+// Package testbin builds small Go programs for tests to inspect
+package testbin
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Module is the module path of the programs built
+const Module = "example.com/app"
+
+// Build writes the supplied files (relative path to contents) to a module within a temporary directory, then builds
+// it for linux/amd64, returning the path of the binary. Arguments are appended to go build, e.g. flags followed by the
+// package to build.
+func Build(t testing.TB, files map[string]string, args ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module "+Module+"\n"), 0o600))
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+		require.NoError(t, os.WriteFile(path, []byte(files[name]), 0o600))
+	}
+
+	cmd := exec.Command("go", append([]string{"build", "-o", "app"}, args...)...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOOS=linux", "GOARCH=amd64", "CGO_ENABLED=0", "GOFLAGS=")
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	return filepath.Join(dir, "app")
+}
+
+// Open builds the supplied files as Build does, returning the opened binary, which is closed once the test completes
+func Open(t testing.TB, files map[string]string, args ...string) *os.File {
+	t.Helper()
+	f, err := os.Open(Build(t, files, args...))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		f.Close()
+	})
+	return f
+}
@@ -2,12 +2,15 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"strings"
 	"text/template"
 
 	"github.com/urfave/cli/v2"
 
+	"github.com/nick-jones/gost/pkg/report"
 	"github.com/nick-jones/gost/pkg/scan"
 )
 
@@ -17,23 +20,79 @@ const tmpl = `{{printf "%x: %q" .Addr .Value}} → {{range $i, $e := .Refs}}
 {{- if gt (len .Refs) 5}}... (truncated, {{len .Refs}} total){{- end -}}
 `
 
+// defaultScope is the scope listed by default: the application's own strings, and those of its dependencies
+const defaultScope = "main,deps"
+
+// scanFlags returns the flags that influence analysis, shared by all commands that scan binaries. Commands that look
+// for particular strings (e.g. environment variables) default to all scopes, since those of the standard library are
+// of as much interest as any.
+func scanFlags(scope string) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "string-table",
+			Usage: `if symbols are missing, use values "guess" or "ignore" to enable more fuzzy matching`,
+		},
+		&cli.BoolFlag{
+			Name:  "nulls",
+			Usage: "string candidates containing null characters will be included",
+		},
+		&cli.StringFlag{
+			Name:  "module",
+			Usage: `only include strings referenced from this module (a module path, "stdlib" or "main module")`,
+		},
+		&cli.StringFlag{
+			Name:  "scope",
+			Usage: `only include strings referenced from these scopes, a comma separated list of "main", "deps" & "std" (or "all")`,
+			Value: scope,
+		},
+		&cli.StringFlag{
+			Name:  "lines",
+			Usage: `source of file & line information, one of "pclntab", "dwarf" or "best" (DWARF where present, otherwise pclntab)`,
+			Value: string(scan.LinesPCLN),
+		},
+		debugDirFlag,
+	}
+}
+
+// debugDirFlag selects a directory holding separate debug files, for stripped binaries
+var debugDirFlag = &cli.StringFlag{
+	Name:  "debug-dir",
+	Usage: "directory of separate debug files (e.g. /usr/lib/debug), matched to stripped ELF binaries by build ID or debug link",
+}
+
+// formatFlag selects the output format of subcommands that write either text or JSON
+var formatFlag = &cli.StringFlag{
+	Name:  "format",
+	Usage: `output format, one of "text" or "json"`,
+	Value: "text",
+}
+
 func main() {
 	app := &cli.App{
 		Name: "gost",
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: `output format, one of "text" (rendered with --template), "json" or "ndjson"`,
+				Value: "text",
+			},
 			&cli.StringFlag{
 				Name:  "template",
 				Usage: "template string for printing the results (format is text/template)",
 				Value: tmpl,
 			},
-			&cli.StringFlag{
-				Name:  "string-table",
-				Usage: `if symbols are missing, use values "guess" or "ignore" to enable more fuzzy matching`,
-			},
-			&cli.BoolFlag{
-				Name:  "nulls",
-				Usage: "string candidates containing null characters will be included",
-			},
+		}, scanFlags(defaultScope)...),
+		Commands: []*cli.Command{
+			diffCommand,
+			envCommand,
+			flagsCommand,
+			routesCommand,
+			secretsCommand,
+			certsCommand,
+			embedCommand,
+			varsCommand,
+			infoCommand,
+			modulesCommand,
 		},
 		Action: run,
 	}
@@ -44,40 +103,77 @@ func main() {
 }
 
 func run(c *cli.Context) error {
-	filePath := c.Args().First()
-	format := c.String("template")
+	write, err := parseFormat(c)
+	if err != nil {
+		return err
+	}
 
-	tmpl, err := template.New("format").Parse(format)
+	results, err := scanFile(c, c.Args().First())
 	if err != nil {
-		return fmt.Errorf("failed to parse format flag: %w", err)
+		return err
 	}
 
+	// print results
+	return write(os.Stdout, results)
+}
+
+// scanFile runs analysis over the binary at the supplied path
+func scanFile(c *cli.Context, filePath string) ([]scan.Result, error) {
 	f, err := os.Open(filePath)
 	if err != nil {
-		return fmt.Errorf("failed to open file: %w", err)
+		return nil, fmt.Errorf("failed to open file: %w", err)
 	}
 	defer f.Close()
 
 	opts, err := parseFlags(c)
 	if err != nil {
-		return fmt.Errorf("failed to parse flags: %w", err)
+		return nil, fmt.Errorf("failed to parse flags: %w", err)
 	}
 
 	// run analysis
 	results, err := scan.Run(f, opts...)
 	if err != nil {
-		return fmt.Errorf("failed to search instructions: %w", err)
+		return nil, fmt.Errorf("failed to search instructions: %w", err)
 	}
+	return results, nil
+}
 
-	// print results
-	for _, res := range results {
-		if err := tmpl.Execute(os.Stdout, res); err != nil {
-			return fmt.Errorf("failed to execute template: %w", err)
+// parseFormat returns a function that writes results in the requested output format
+func parseFormat(c *cli.Context) (func(io.Writer, []scan.Result) error, error) {
+	switch format := c.String("format"); format {
+	case "text":
+		tmpl, err := template.New("format").Parse(c.String("template"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template flag: %w", err)
 		}
-		fmt.Println()
+		return func(w io.Writer, results []scan.Result) error {
+			for _, res := range results {
+				if err := tmpl.Execute(w, res); err != nil {
+					return fmt.Errorf("failed to execute template: %w", err)
+				}
+				fmt.Fprintln(w)
+			}
+			return nil
+		}, nil
+	case "json":
+		return report.WriteJSON, nil
+	case "ndjson":
+		return report.WriteNDJSON, nil
+	default:
+		return nil, fmt.Errorf("invalid format flag value: %s", format)
 	}
+}
 
-	return nil
+// formatWriter returns whichever of the supplied writers is selected by the format flag of a subcommand
+func formatWriter[T any](c *cli.Context, text, json func(io.Writer, T) error) (func(io.Writer, T) error, error) {
+	switch format := c.String("format"); format {
+	case "text":
+		return text, nil
+	case "json":
+		return json, nil
+	default:
+		return nil, fmt.Errorf("invalid format flag value: %s", format)
+	}
 }
 
 func parseFlags(c *cli.Context) ([]scan.Option, error) {
@@ -97,5 +193,31 @@ func parseFlags(c *cli.Context) ([]scan.Option, error) {
 		opts = append(opts, scan.WithNullsPermitted())
 	}
 
+	if module := c.String("module"); module != "" {
+		opts = append(opts, scan.WithModule(module))
+	}
+
+	if flag := c.String("scope"); flag != "all" {
+		scopes := make([]scan.Scope, 0)
+		for _, s := range strings.Split(flag, ",") {
+			scope, err := scan.ParseScope(strings.TrimSpace(s))
+			if err != nil {
+				return nil, err
+			}
+			scopes = append(scopes, scope)
+		}
+		opts = append(opts, scan.WithScope(scopes...))
+	}
+
+	src, err := scan.ParseLineSource(c.String("lines"))
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, scan.WithLineSource(src))
+
+	if dir := c.String("debug-dir"); dir != "" {
+		opts = append(opts, scan.WithDebugDir(dir), scan.WithWarnings(c.App.ErrWriter))
+	}
+
 	return opts, nil
 }
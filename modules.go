@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/nick-jones/gost/pkg/inventory"
+)
+
+var modulesCommand = &cli.Command{
+	Name:      "modules",
+	Usage:     "summarise the strings referenced from each module",
+	ArgsUsage: "<binary>",
+	Flags: append([]cli.Flag{
+		formatFlag,
+	}, scanFlags("all")...),
+	Action: runModules,
+}
+
+func runModules(c *cli.Context) error {
+	write, err := formatWriter(c, inventory.WriteModulesText, inventory.WriteModulesJSON)
+	if err != nil {
+		return err
+	}
+
+	results, err := scanFile(c, c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	return write(os.Stdout, inventory.Modules(results))
+}
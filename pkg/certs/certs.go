@@ -0,0 +1,363 @@
+// Package certs locates X.509 certificates and keys embedded in a binary. PEM blocks and DER encoded certificates are
+// searched for in the data sections, then linked to the code referencing them, either directly or by way of scan
+// results containing them.
+package certs
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nick-jones/gost/internal/exe"
+	"github.com/nick-jones/gost/pkg/report"
+	"github.com/nick-jones/gost/pkg/scan"
+)
+
+// Kinds of item found
+const (
+	KindCertificate = "certificate"
+	KindPrivateKey  = "private-key"
+	KindPublicKey   = "public-key"
+)
+
+var pemStart = []byte("-----BEGIN ")
+
+// Item is a certificate or key found in the binary
+type Item struct {
+	Kind      string         `json:"kind"`
+	Addr      report.Address `json:"address"`
+	Encoding  string         `json:"encoding"`           // PEM or DER
+	PEMType   string         `json:"pem_type,omitempty"` // e.g. CERTIFICATE, RSA PRIVATE KEY
+	KeyType   string         `json:"key_type,omitempty"` // e.g. RSA-2048, ECDSA-P256
+	Subject   string         `json:"subject,omitempty"`
+	Issuer    string         `json:"issuer,omitempty"`
+	SANs      []string       `json:"sans,omitempty"`
+	IsCA      bool           `json:"is_ca,omitempty"`
+	NotBefore *time.Time     `json:"not_before,omitempty"` // nil unless a parsed certificate
+	NotAfter  *time.Time     `json:"not_after,omitempty"`
+	Locations []Location     `json:"locations"`
+}
+
+// Location is where an item is referenced from
+type Location struct {
+	Symbol string `json:"symbol,omitempty"`
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+}
+
+// Find searches the data sections of the binary for certificates & keys. Results from a scan of the same binary are
+// used to determine where each item is referenced from. Items are ordered by address.
+func Find(r io.ReaderAt, results []scan.Result) ([]Item, error) {
+	f, err := exe.New(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file: %w", err)
+	}
+	data, err := f.LoadedSections(func(s exe.Section) bool {
+		return isDataSection(s.Name)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	items := findItems(data)
+	if err := link(r, f, data, items, results); err != nil {
+		return nil, err
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Addr < items[j].Addr
+	})
+	return items, nil
+}
+
+// findItems searches the section data for PEM blocks & DER encoded certificates
+func findItems(data []exe.SectionData) []Item {
+	items := make([]Item, 0)
+	for _, sect := range data {
+		for _, item := range findPEM(sect.Bytes) {
+			item.Addr += report.Address(sect.AddrRange.Start)
+			items = append(items, item)
+		}
+		for _, item := range findDER(sect.Bytes) {
+			item.Addr += report.Address(sect.AddrRange.Start)
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// link populates the locations of each item, from the scan results containing it & the instructions referencing it
+func link(r io.ReaderAt, f *exe.File, data []exe.SectionData, items []Item, results []scan.Result) error {
+	sorted := append([]scan.Result(nil), results...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Addr < sorted[j].Addr
+	})
+	// Blobs converted to byte slices are copied from their address rather than referenced as strings, so instructions
+	// referencing each item directly are located too. Embedded files are instead reached via the header of the
+	// variable they are embedded in, so headers pointing at each item are followed.
+	addrs := make([]uint64, 0, len(items))
+	headers := make(map[uint64][]uint64)
+	for _, item := range items {
+		addrs = append(addrs, uint64(item.Addr))
+		for _, hdr := range findPointers(f, data, uint64(item.Addr)) {
+			headers[uint64(item.Addr)] = append(headers[uint64(item.Addr)], hdr)
+			addrs = append(addrs, hdr)
+		}
+	}
+	direct, err := scan.FindReferences(r, addrs)
+	if err != nil {
+		return fmt.Errorf("failed to find references: %w", err)
+	}
+	for i := range items {
+		addr := uint64(items[i].Addr)
+		locs := append(locate(addr, sorted), toLocations(direct[addr])...)
+		for _, hdr := range headers[addr] {
+			locs = append(locs, toLocations(direct[hdr])...)
+		}
+		items[i].Locations = dedupeLocations(locs)
+	}
+	return nil
+}
+
+// isDataSection returns true for sections that may hold constant data (e.g. .rodata, __rodata, .rdata, .noptrdata)
+func isDataSection(name string) bool {
+	name = strings.ToLower(name)
+	return strings.Contains(name, "data") && !strings.Contains(name, "rel")
+}
+
+// findPEM decodes every PEM block in the data. Item addresses are offsets into the data.
+func findPEM(data []byte) []Item {
+	items := make([]Item, 0)
+	for off := 0; off < len(data); {
+		i := bytes.Index(data[off:], pemStart)
+		if i < 0 {
+			break
+		}
+		start := off + i
+		block, rest := pem.Decode(data[start:])
+		if block == nil {
+			off = start + len(pemStart)
+			continue
+		}
+		if item, ok := parseBlock(block); ok {
+			item.Addr = report.Address(start)
+			items = append(items, item)
+		}
+		off = len(data) - len(rest)
+	}
+	return items
+}
+
+// parseBlock interprets a PEM block, reporting not ok for block types that are not certificates or keys
+func parseBlock(block *pem.Block) (Item, bool) {
+	item := Item{Encoding: "PEM", PEMType: block.Type}
+	switch {
+	case block.Type == "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			item.Kind = KindCertificate // unparsable, but still worth reporting
+			return item, true
+		}
+		return describeCertificate(item, cert), true
+	case strings.HasSuffix(block.Type, "PRIVATE KEY"):
+		item.Kind = KindPrivateKey
+		item.KeyType = privateKeyType(block)
+		return item, true
+	case strings.HasSuffix(block.Type, "PUBLIC KEY"):
+		item.Kind = KindPublicKey
+		if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+			item.KeyType = keyType(key)
+		}
+		return item, true
+	default:
+		return Item{}, false
+	}
+}
+
+// findDER searches for DER encoded certificates. A certificate is a SEQUENCE with a long form length, whose first
+// element (the TBSCertificate) is also a SEQUENCE; candidates are confirmed by parsing. Item addresses are offsets
+// into the data.
+func findDER(data []byte) []Item {
+	items := make([]Item, 0)
+	for i := 0; i+8 <= len(data); i++ {
+		if data[i] != 0x30 || data[i+1] != 0x82 || data[i+4] != 0x30 || data[i+5] != 0x82 {
+			continue
+		}
+		end := i + 4 + (int(data[i+2])<<8 | int(data[i+3]))
+		if end > len(data) {
+			continue
+		}
+		cert, err := x509.ParseCertificate(data[i:end])
+		if err != nil {
+			continue
+		}
+		item := describeCertificate(Item{Encoding: "DER", Addr: report.Address(i)}, cert)
+		items = append(items, item)
+		i = end - 1
+	}
+	return items
+}
+
+// describeCertificate populates the item with details of the certificate
+func describeCertificate(item Item, cert *x509.Certificate) Item {
+	item.Kind = KindCertificate
+	item.Subject = cert.Subject.String()
+	item.Issuer = cert.Issuer.String()
+	item.IsCA = cert.IsCA
+	notBefore, notAfter := cert.NotBefore.UTC(), cert.NotAfter.UTC()
+	item.NotBefore, item.NotAfter = &notBefore, &notAfter
+	item.KeyType = keyType(cert.PublicKey)
+	item.SANs = append(item.SANs, cert.DNSNames...)
+	item.SANs = append(item.SANs, cert.EmailAddresses...)
+	for _, ip := range cert.IPAddresses {
+		item.SANs = append(item.SANs, ip.String())
+	}
+	for _, u := range cert.URIs {
+		item.SANs = append(item.SANs, u.String())
+	}
+	return item
+}
+
+// privateKeyType attempts each private key encoding in turn, returning the key type if one succeeds
+func privateKeyType(block *pem.Block) string {
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return keyType(key)
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return keyType(key)
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return keyType(key)
+	}
+	return ""
+}
+
+// keyType describes a public or private key, e.g. RSA-2048
+func keyType(key interface{}) string {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA-%d", k.N.BitLen())
+	case *rsa.PrivateKey:
+		return fmt.Sprintf("RSA-%d", k.N.BitLen())
+	case *ecdsa.PublicKey:
+		return "ECDSA-" + k.Curve.Params().Name
+	case *ecdsa.PrivateKey:
+		return "ECDSA-" + k.Curve.Params().Name
+	case ed25519.PublicKey, ed25519.PrivateKey:
+		return "Ed25519"
+	default:
+		return ""
+	}
+}
+
+// locate returns the locations of references to any result containing the address. Results must be ordered by
+// address.
+func locate(addr uint64, results []scan.Result) []Location {
+	locs := make([]Location, 0)
+	i := sort.Search(len(results), func(i int) bool {
+		return results[i].Addr > addr
+	})
+	// walk back over results starting at or before the address, since strings may overlap
+	for j := i - 1; j >= 0; j-- {
+		res := results[j]
+		if addr >= res.Addr+uint64(len(res.Value)) {
+			if addr-res.Addr > 1<<20 {
+				break // no string is this large, so nothing further back can contain the address
+			}
+			continue
+		}
+		locs = append(locs, toLocations(res.Refs)...)
+	}
+	return locs
+}
+
+// findPointers returns the addresses of pointer sized words in the data sections holding the supplied address
+func findPointers(f *exe.File, data []exe.SectionData, addr uint64) []uint64 {
+	ptr := make([]byte, 8)
+	f.ByteOrder().PutUint64(ptr, addr)
+	found := make([]uint64, 0)
+	for _, sect := range data {
+		for off := 0; off+8 <= len(sect.Bytes); off += 8 {
+			if bytes.Equal(sect.Bytes[off:off+8], ptr) {
+				found = append(found, sect.AddrRange.Start+uint64(off))
+			}
+		}
+	}
+	return found
+}
+
+// toLocations converts references to locations
+func toLocations(refs []scan.Reference) []Location {
+	locs := make([]Location, 0, len(refs))
+	for _, ref := range refs {
+		locs = append(locs, Location{Symbol: ref.SymbolName, File: ref.File, Line: ref.Line})
+	}
+	return locs
+}
+
+// dedupeLocations removes repeated locations, preserving order
+func dedupeLocations(locs []Location) []Location {
+	seen := make(map[Location]bool, len(locs))
+	deduped := make([]Location, 0, len(locs))
+	for _, loc := range locs {
+		if !seen[loc] {
+			seen[loc] = true
+			deduped = append(deduped, loc)
+		}
+	}
+	return deduped
+}
+
+// ExpiringWithin returns the certificates that expire before the supplied time
+func ExpiringWithin(items []Item, before time.Time) []Item {
+	expiring := make([]Item, 0)
+	for _, item := range items {
+		if item.Kind == KindCertificate && item.NotAfter != nil && item.NotAfter.Before(before) {
+			expiring = append(expiring, item)
+		}
+	}
+	return expiring
+}
+
+// WriteText writes a summary of each item, followed by the locations referencing it
+func WriteText(w io.Writer, items []Item) error {
+	for _, item := range items {
+		var b strings.Builder
+		fmt.Fprintf(&b, "%x: %s (%s", uint64(item.Addr), item.Kind, item.Encoding)
+		if item.KeyType != "" {
+			fmt.Fprintf(&b, ", %s", item.KeyType)
+		}
+		b.WriteString(")")
+		if item.Kind == KindCertificate && item.Subject != "" {
+			fmt.Fprintf(&b, "\n  subject: %s\n  issuer: %s", item.Subject, item.Issuer)
+			if len(item.SANs) > 0 {
+				fmt.Fprintf(&b, "\n  sans: %s", strings.Join(item.SANs, ", "))
+			}
+			if item.NotAfter != nil {
+				fmt.Fprintf(&b, "\n  expires: %s", item.NotAfter.Format(time.RFC3339))
+			}
+			if item.IsCA {
+				b.WriteString(" (CA)")
+			}
+		}
+		for _, loc := range item.Locations {
+			fmt.Fprintf(&b, "\n  → %s:%d (%s)", loc.File, loc.Line, loc.Symbol)
+		}
+		if _, err := fmt.Fprintln(w, b.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the items as a JSON document
+func WriteJSON(w io.Writer, items []Item) error {
+	return report.WriteVersionedJSON(w, "items", items)
+}
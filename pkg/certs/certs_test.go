@@ -0,0 +1,136 @@
+package certs_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nick-jones/gost/internal/testbin"
+	"github.com/nick-jones/gost/pkg/certs"
+	"github.com/nick-jones/gost/pkg/scan"
+)
+
+func TestFind(t *testing.T) {
+	// the certificate is embedded both as PEM, and as DER (held by a byte slice, so referenced via its header)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	notAfter := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test.internal"},
+		DNSNames:     []string{"test.internal"},
+		NotBefore:    notAfter.AddDate(-1, 0, 0),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &rsaKey.PublicKey, rsaKey)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	require.NoError(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDER})
+	src := fmt.Sprintf("package main\n\nimport \"fmt\"\n\nconst certPEM = %q\n\nconst keyPEM = %q\n\n"+
+		"var certDER = []byte(%q)\n\nfunc main() {\n\tfmt.Println(certPEM, keyPEM, certDER)\n}\n",
+		certPEM, keyPEM, der)
+	f := testbin.Open(t, map[string]string{"main.go": src})
+	results, err := scan.Run(f, scan.WithScope(scan.ScopeMain))
+	require.NoError(t, err)
+
+	items, err := certs.Find(f, results)
+	require.NoError(t, err)
+	byEncoding := make(map[string]certs.Item)
+	for _, item := range items {
+		byEncoding[item.Kind+" "+item.Encoding] = item
+	}
+	require.Len(t, byEncoding, 3, "%+v", items)
+
+	for _, name := range []string{"certificate PEM", "certificate DER"} {
+		cert := byEncoding[name]
+		assert.Equal(t, "CN=test.internal", cert.Subject, name)
+		assert.Equal(t, "RSA-2048", cert.KeyType, name)
+		assert.Equal(t, []string{"test.internal"}, cert.SANs, name)
+		if assert.NotNil(t, cert.NotAfter, name) {
+			assert.Equal(t, notAfter, *cert.NotAfter, name)
+		}
+		require.NotEmpty(t, cert.Locations, name)
+		assert.Equal(t, "main.main", cert.Locations[0].Symbol, name)
+		assert.Equal(t, "main.go", filepath.Base(cert.Locations[0].File), name)
+	}
+	assert.Equal(t, "CERTIFICATE", byEncoding["certificate PEM"].PEMType)
+
+	key := byEncoding["private-key PEM"]
+	assert.Equal(t, "EC PRIVATE KEY", key.PEMType)
+	assert.Equal(t, "ECDSA-P-256", key.KeyType)
+	assert.Nil(t, key.NotBefore)
+	assert.Nil(t, key.NotAfter)
+	data, err := json.Marshal(key)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "not_after")
+}
+
+func TestExpiringWithin(t *testing.T) {
+	now := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	soon, later := now.Add(24*time.Hour), now.Add(90*24*time.Hour)
+	items := []certs.Item{
+		{Kind: certs.KindCertificate, Addr: 0x10, NotAfter: &soon},
+		{Kind: certs.KindCertificate, Addr: 0x20, NotAfter: &later},
+		{Kind: certs.KindCertificate, Addr: 0x30}, // unparsable, so expiry unknown
+		{Kind: certs.KindPrivateKey, Addr: 0x40},
+	}
+
+	expiring := certs.ExpiringWithin(items, now.Add(30*24*time.Hour))
+	require.Len(t, expiring, 1)
+	assert.Equal(t, uint64(0x10), uint64(expiring[0].Addr))
+}
+
+func TestWriteText(t *testing.T) {
+	notAfter := time.Date(2026, 11, 6, 20, 44, 5, 0, time.UTC)
+	items := []certs.Item{
+		{
+			Kind:      certs.KindCertificate,
+			Addr:      0x54d4a6,
+			Encoding:  "PEM",
+			KeyType:   "RSA-2048",
+			Subject:   "CN=internal-ca",
+			Issuer:    "CN=internal-ca",
+			SANs:      []string{"ca.internal", "10.0.0.1"},
+			IsCA:      true,
+			NotAfter:  &notAfter,
+			Locations: []certs.Location{{Symbol: "main.main", File: "main.go", Line: 65}},
+		},
+		{
+			Kind:     certs.KindPrivateKey,
+			Addr:     0x54d92d,
+			Encoding: "PEM",
+			KeyType:  "RSA-2048",
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, certs.WriteText(buf, items))
+	expected := `54d4a6: certificate (PEM, RSA-2048)
+  subject: CN=internal-ca
+  issuer: CN=internal-ca
+  sans: ca.internal, 10.0.0.1
+  expires: 2026-11-06T20:44:05Z (CA)
+  → main.go:65 (main.main)
+54d92d: private-key (PEM, RSA-2048)
+`
+	assert.Equal(t, expected, buf.String())
+}
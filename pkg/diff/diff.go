@@ -0,0 +1,183 @@
+// Package diff compares the strings found in 2 builds of a binary. Results are matched by value, since addresses shift
+// between builds, and the locations referencing each value are compared to identify strings that have moved. Files are
+// compared as -trimpath records them, so builds made in different directories are comparable.
+package diff
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/nick-jones/gost/pkg/report"
+	"github.com/nick-jones/gost/pkg/scan"
+)
+
+// Diff describes the differences between 2 sets of results
+type Diff struct {
+	Added   []Entry `json:"added"`   // strings only present in the new build
+	Removed []Entry `json:"removed"` // strings only present in the old build
+	Moved   []Move  `json:"moved"`   // strings present in both, but referenced from different locations
+}
+
+// Entry is a string along with the locations it is referenced from
+type Entry struct {
+	Value     string     `json:"value"`
+	Locations []Location `json:"locations"`
+}
+
+// Move is a string whose references differ between builds
+type Move struct {
+	Value string     `json:"value"`
+	Old   []Location `json:"old"`
+	New   []Location `json:"new"`
+}
+
+// Location is where a reference is made from. Addresses are deliberately omitted, as they are not comparable.
+type Location struct {
+	Symbol string `json:"symbol,omitempty"`
+	File   string `json:"file,omitempty"` // as -trimpath records it, where known (e.g. example.com/app/main.go)
+	Line   int    `json:"line,omitempty"`
+}
+
+// String returns the location in the form file:line (symbol)
+func (l Location) String() string {
+	if l.Symbol == "" {
+		return fmt.Sprintf("%s:%d", l.File, l.Line)
+	}
+	return fmt.Sprintf("%s:%d (%s)", l.File, l.Line, l.Symbol)
+}
+
+// Compare compares results from the old and new builds. Everything returned is ordered by value, so the diff is deterministic.
+func Compare(oldResults, newResults []scan.Result) Diff {
+	oldLocs, newLocs := locationsByValue(oldResults), locationsByValue(newResults)
+
+	d := Diff{
+		Added:   make([]Entry, 0),
+		Removed: make([]Entry, 0),
+		Moved:   make([]Move, 0),
+	}
+	for _, value := range sortedKeys(newLocs) {
+		if _, found := oldLocs[value]; !found {
+			d.Added = append(d.Added, Entry{Value: value, Locations: newLocs[value]})
+		}
+	}
+	for _, value := range sortedKeys(oldLocs) {
+		nl, found := newLocs[value]
+		if !found {
+			d.Removed = append(d.Removed, Entry{Value: value, Locations: oldLocs[value]})
+			continue
+		}
+		if !equalLocations(oldLocs[value], nl) {
+			d.Moved = append(d.Moved, Move{Value: value, Old: oldLocs[value], New: nl})
+		}
+	}
+	return d
+}
+
+// Empty returns true if no differences were found
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Moved) == 0
+}
+
+// locationsByValue groups the reference locations of each value. The same value may reside at more than one address,
+// in which case the locations are merged. Locations are sorted & deduplicated.
+func locationsByValue(results []scan.Result) map[string][]Location {
+	grouped := make(map[string][]Location)
+	for _, res := range results {
+		locs := grouped[res.Value]
+		if locs == nil {
+			locs = make([]Location, 0, len(res.Refs))
+		}
+		for _, ref := range res.Refs {
+			file := ref.TrimmedFile
+			if file == "" {
+				file = ref.File
+			}
+			locs = append(locs, Location{Symbol: ref.SymbolName, File: file, Line: ref.Line})
+		}
+		grouped[res.Value] = locs
+	}
+	for value, locs := range grouped {
+		sort.Slice(locs, func(i, j int) bool {
+			return lessLocation(locs[i], locs[j])
+		})
+		deduped := locs[:0]
+		for i, loc := range locs {
+			if i == 0 || loc != locs[i-1] {
+				deduped = append(deduped, loc)
+			}
+		}
+		grouped[value] = deduped
+	}
+	return grouped
+}
+
+// lessLocation orders locations by file, line and then symbol
+func lessLocation(a, b Location) bool {
+	if a.File != b.File {
+		return a.File < b.File
+	}
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	return a.Symbol < b.Symbol
+}
+
+// equalLocations returns true if both sorted slices hold the same locations
+func equalLocations(a, b []Location) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// sortedKeys returns the keys of the map in ascending order
+func sortedKeys(m map[string][]Location) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// WriteText writes the diff in a human readable form. Added strings are prefixed with +, removed with - and moved
+// with ~, followed by the old and new locations.
+func WriteText(w io.Writer, d Diff) error {
+	for _, e := range d.Added {
+		if _, err := fmt.Fprintf(w, "+ %q → %s\n", e.Value, joinLocations(e.Locations)); err != nil {
+			return err
+		}
+	}
+	for _, e := range d.Removed {
+		if _, err := fmt.Fprintf(w, "- %q → %s\n", e.Value, joinLocations(e.Locations)); err != nil {
+			return err
+		}
+	}
+	for _, m := range d.Moved {
+		if _, err := fmt.Fprintf(w, "~ %q → %s ⇒ %s\n", m.Value, joinLocations(m.Old), joinLocations(m.New)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// joinLocations renders locations separated by spaces
+func joinLocations(locs []Location) string {
+	strs := make([]string, len(locs))
+	for i, loc := range locs {
+		strs[i] = loc.String()
+	}
+	return strings.Join(strs, " ")
+}
+
+// WriteJSON writes the diff as a JSON document, with the added, removed & moved strings at the top level
+func WriteJSON(w io.Writer, d Diff) error {
+	return report.WriteVersionedJSON(w, "", d)
+}
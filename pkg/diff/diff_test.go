@@ -0,0 +1,91 @@
+package diff_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nick-jones/gost/internal/testbin"
+	"github.com/nick-jones/gost/pkg/diff"
+	"github.com/nick-jones/gost/pkg/scan"
+)
+
+func TestCompare(t *testing.T) {
+	oldResults := []scan.Result{
+		{Addr: 0x1000, Value: "unchanged", Refs: []scan.Reference{{Addr: 0x10, SymbolName: "main.a", File: "a.go", Line: 1}}},
+		{Addr: 0x1010, Value: "removed", Refs: []scan.Reference{{Addr: 0x20, SymbolName: "main.a", File: "a.go", Line: 2}}},
+		{Addr: 0x1020, Value: "moved", Refs: []scan.Reference{{Addr: 0x30, SymbolName: "main.a", File: "a.go", Line: 3}}},
+	}
+	newResults := []scan.Result{
+		// addresses shift between builds, which must not register as a difference
+		{Addr: 0x2000, Value: "unchanged", Refs: []scan.Reference{{Addr: 0x50, SymbolName: "main.a", File: "a.go", Line: 1}}},
+		{Addr: 0x2010, Value: "moved", Refs: []scan.Reference{{Addr: 0x60, SymbolName: "main.b", File: "b.go", Line: 9}}},
+		{Addr: 0x2020, Value: "added", Refs: []scan.Reference{{Addr: 0x70, SymbolName: "main.b", File: "b.go", Line: 5}}},
+	}
+
+	expected := diff.Diff{
+		Added: []diff.Entry{
+			{Value: "added", Locations: []diff.Location{{Symbol: "main.b", File: "b.go", Line: 5}}},
+		},
+		Removed: []diff.Entry{
+			{Value: "removed", Locations: []diff.Location{{Symbol: "main.a", File: "a.go", Line: 2}}},
+		},
+		Moved: []diff.Move{
+			{
+				Value: "moved",
+				Old:   []diff.Location{{Symbol: "main.a", File: "a.go", Line: 3}},
+				New:   []diff.Location{{Symbol: "main.b", File: "b.go", Line: 9}},
+			},
+		},
+	}
+	assert.Equal(t, expected, diff.Compare(oldResults, newResults))
+}
+
+func TestCompare_Identical(t *testing.T) {
+	results := []scan.Result{
+		{Addr: 0x1000, Value: "banana", Refs: []scan.Reference{{Addr: 0x10, File: "a.go", Line: 1}}},
+	}
+	assert.True(t, diff.Compare(results, results).Empty())
+}
+
+func TestWriteText(t *testing.T) {
+	d := diff.Diff{
+		Added:   []diff.Entry{{Value: "added", Locations: []diff.Location{{Symbol: "main.b", File: "b.go", Line: 5}}}},
+		Removed: []diff.Entry{{Value: "removed", Locations: []diff.Location{{File: "a.go", Line: 2}}}},
+		Moved: []diff.Move{{
+			Value: "moved",
+			Old:   []diff.Location{{Symbol: "main.a", File: "a.go", Line: 3}},
+			New:   []diff.Location{{Symbol: "main.b", File: "b.go", Line: 9}},
+		}},
+	}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, diff.WriteText(buf, d))
+
+	expected := `+ "added" → b.go:5 (main.b)
+- "removed" → a.go:2
+~ "moved" → a.go:3 (main.a) ⇒ b.go:9 (main.b)
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestCompare_BuildDirectories(t *testing.T) {
+	src := "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"banana\")\n}\n"
+	build := func(flags ...string) []scan.Result {
+		f := testbin.Open(t, map[string]string{"cmd/app/main.go": src}, append(flags, "./cmd/app")...)
+		results, err := scan.Run(f, scan.WithScope(scan.ScopeMain))
+		require.NoError(t, err)
+		return results
+	}
+
+	// the same source built in 2 directories (one with -trimpath) differs only in file paths, which must not register
+	oldResults, newResults := build(), build("-trimpath")
+	assert.NotEqual(t, oldResults[0].Refs[0].File, newResults[0].Refs[0].File)
+	d := diff.Compare(oldResults, newResults)
+	assert.True(t, d.Empty(), "%+v", d)
+
+	locs := diff.Compare(nil, newResults).Added[0].Locations
+	assert.Equal(t, "example.com/app/cmd/app/main.go", locs[0].File)
+}
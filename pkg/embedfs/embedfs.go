@@ -0,0 +1,237 @@
+// Package embedfs recovers the file trees of embed.FS variables from a binary. The compiler lays each out as a slice
+// header immediately followed by the file table it points to, where every entry holds the name, data and hash of a file
+// (or directory). These are located by searching for slice headers that point just past themselves.
+package embedfs
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nick-jones/gost/internal/address"
+	"github.com/nick-jones/gost/internal/exe"
+	"github.com/nick-jones/gost/pkg/report"
+)
+
+const (
+	// headerSize is the size of the slice header preceding the file table
+	headerSize = 24
+	// entrySize is the size of each file table entry: the name & data string headers, followed by a 16 byte hash
+	entrySize = 48
+	// maxFiles bounds the length of tables considered, so that garbage is rejected without reading it
+	maxFiles = 1 << 20
+	// maxNameLen bounds the length of file names considered
+	maxNameLen = 4096
+)
+
+// FS is an embedded file system
+type FS struct {
+	Name  string         `json:"name,omitempty"` // variable name (if symbols are present), e.g. main.static
+	Addr  report.Address `json:"address"`        // address of the file table
+	Files []File         `json:"files"`
+}
+
+// String returns the variable name if known, otherwise the address of the file table
+func (f FS) String() string {
+	if f.Name != "" {
+		return f.Name
+	}
+	return fmt.Sprintf("%x", uint64(f.Addr))
+}
+
+// File is a file or directory within an embedded file system
+type File struct {
+	Name string         `json:"name"` // path within the file system; directories carry a trailing slash
+	Dir  bool           `json:"dir,omitempty"`
+	Size int            `json:"size"`
+	Hash string         `json:"hash,omitempty"` // hash recorded by the compiler (hex encoded)
+	Addr report.Address `json:"address,omitempty"`
+	Data []byte         `json:"-"`
+}
+
+// Find searches the binary for embedded file systems. File systems are ordered by address, and files are ordered as
+// the compiler recorded them.
+func Find(r io.ReaderAt) ([]FS, error) {
+	f, err := exe.New(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file: %w", err)
+	}
+	txt, err := f.TextSection()
+	if err != nil {
+		return nil, err
+	}
+	sects, err := f.LoadedSections(func(s exe.Section) bool {
+		return s.AddrRange != txt.AddrRange // instructions
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bo := f.ByteOrder()
+	found := make([]FS, 0)
+	for _, sect := range sects {
+		data := sect.Bytes
+		for off := 0; off+headerSize <= len(data); off += 8 {
+			addr := sect.AddrRange.Start + uint64(off)
+			if bo.Uint64(data[off:]) != addr+headerSize {
+				continue
+			}
+			n, c := bo.Uint64(data[off+8:]), bo.Uint64(data[off+16:])
+			if n == 0 || n != c || n > maxFiles || uint64(len(data)-off-headerSize) < n*entrySize {
+				continue
+			}
+			files, ok := readFiles(f, data[off+headerSize:off+headerSize+int(n)*entrySize])
+			if !ok {
+				continue
+			}
+			found = append(found, FS{Addr: report.Address(addr), Files: files})
+			off += headerSize + int(n)*entrySize - 8
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].Addr < found[j].Addr
+	})
+
+	// the compiler names the file table after the variable, e.g. main.static.files
+	for i, fsys := range found {
+		if sym, err := f.SymbolAt(uint64(fsys.Addr)); err == nil {
+			found[i].Name = strings.TrimSuffix(sym.Name, ".files")
+		}
+	}
+	return found, nil
+}
+
+// readFiles decodes the entries of a file table, reporting not ok if they do not look like a file system
+func readFiles(f *exe.File, table []byte) ([]File, bool) {
+	bo := f.ByteOrder()
+	files := make([]File, 0, len(table)/entrySize)
+	dirs := make(map[string]bool)
+	for off := 0; off < len(table); off += entrySize {
+		nameAddr, nameLen := bo.Uint64(table[off:]), bo.Uint64(table[off+8:])
+		dataAddr, dataLen := bo.Uint64(table[off+16:]), bo.Uint64(table[off+24:])
+		if nameLen == 0 || nameLen > maxNameLen {
+			return nil, false
+		}
+		name, err := read(f, nameAddr, nameLen)
+		if err != nil {
+			return nil, false
+		}
+		file := File{
+			Name: string(name),
+			Dir:  strings.HasSuffix(string(name), "/"),
+			Size: int(dataLen),
+			Addr: report.Address(dataAddr),
+			Hash: hex.EncodeToString(table[off+32 : off+entrySize]),
+		}
+		if !fs.ValidPath(strings.TrimSuffix(file.Name, "/")) {
+			return nil, false
+		}
+		if file.Dir {
+			if dataLen != 0 {
+				return nil, false
+			}
+			file.Addr, file.Hash = 0, ""
+			dirs[file.Name] = true
+		} else if dataLen > 0 {
+			if file.Data, err = read(f, dataAddr, dataLen); err != nil {
+				return nil, false
+			}
+		}
+		files = append(files, file)
+	}
+
+	// every directory leading to an entry is itself an entry
+	for _, file := range files {
+		if parent := path.Dir(strings.TrimSuffix(file.Name, "/")); parent != "." && !dirs[parent+"/"] {
+			return nil, false
+		}
+	}
+	return files, true
+}
+
+// read returns the data at the supplied address
+func read(f *exe.File, addr, size uint64) ([]byte, error) {
+	sect, err := f.SectionContainingRange(address.Range{Start: addr, End: addr + size})
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, size)
+	if _, err := sect.ReadAt(buf, int64(addr-sect.AddrRange.Start)); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// Extract writes the files of the file system beneath the supplied directory
+func Extract(fsys FS, dir string) error {
+	for _, file := range fsys.Files {
+		dst, ok := destination(dir, file.Name)
+		if !ok {
+			return fmt.Errorf("invalid file name: %q", file.Name)
+		}
+		if file.Dir {
+			if err := os.MkdirAll(dst, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(dst, file.Data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// destination returns the path within the directory that a file is extracted to. Names are rejected if they are not
+// valid slash separated paths, or contain backslashes (which Windows treats as separators), or otherwise escape the
+// directory once joined.
+func destination(dir, name string) (string, bool) {
+	name = strings.TrimSuffix(name, "/")
+	if !fs.ValidPath(name) || strings.Contains(name, `\`) {
+		return "", false
+	}
+	dst := filepath.Join(dir, filepath.FromSlash(name))
+	rel, err := filepath.Rel(dir, dst)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return dst, true
+}
+
+// WriteText writes each file system, followed by the files within it
+func WriteText(w io.Writer, fss []FS) error {
+	for _, fsys := range fss {
+		header := fsys.String()
+		if fsys.Name != "" {
+			header += fmt.Sprintf(" (%x)", uint64(fsys.Addr))
+		}
+		if _, err := fmt.Fprintln(w, header); err != nil {
+			return err
+		}
+		for _, file := range fsys.Files {
+			line := "  " + file.Name
+			if !file.Dir {
+				line += fmt.Sprintf(" (%d bytes)", file.Size)
+			}
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the file systems as a JSON document, listed under "filesystems"
+func WriteJSON(w io.Writer, fss []FS) error {
+	return report.WriteVersionedJSON(w, "filesystems", fss)
+}
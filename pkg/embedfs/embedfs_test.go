@@ -0,0 +1,84 @@
+package embedfs_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nick-jones/gost/internal/testbin"
+	"github.com/nick-jones/gost/pkg/embedfs"
+)
+
+var static = embedfs.FS{
+	Name: "main.static",
+	Addr: 0x4bb280,
+	Files: []embedfs.File{
+		{Name: "static/", Dir: true},
+		{Name: "static/css/", Dir: true},
+		{Name: "static/index.html", Size: 12, Data: []byte("<h1>hi</h1>\n")},
+		{Name: "static/css/site.css", Size: 16, Data: []byte("body{color:red}\n")},
+	},
+}
+
+func TestExtract(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, embedfs.Extract(static, dir))
+
+	data, err := os.ReadFile(filepath.Join(dir, "static", "css", "site.css"))
+	require.NoError(t, err)
+	assert.Equal(t, "body{color:red}\n", string(data))
+
+	data, err = os.ReadFile(filepath.Join(dir, "static", "index.html"))
+	require.NoError(t, err)
+	assert.Equal(t, "<h1>hi</h1>\n", string(data))
+}
+
+func TestExtract_InvalidName(t *testing.T) {
+	for _, name := range []string{"../escape", "/abs", `a\..\..\escape`, `static\index.html`, "a/../../escape"} {
+		fsys := embedfs.FS{Files: []embedfs.File{{Name: name, Data: []byte("x")}}}
+		assert.Error(t, embedfs.Extract(fsys, t.TempDir()), name)
+	}
+}
+
+func TestFind(t *testing.T) {
+	src := "package main\n\nimport (\n\t\"embed\"\n\t\"fmt\"\n)\n\n//go:embed static\nvar static embed.FS\n\n" +
+		"func main() {\n\tfmt.Println(static.ReadDir(\".\"))\n}\n"
+	files := map[string]string{"main.go": src}
+	for _, file := range static.Files {
+		if !file.Dir {
+			files[file.Name] = string(file.Data)
+		}
+	}
+	f := testbin.Open(t, files)
+
+	found, err := embedfs.Find(f)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, static.Name, found[0].Name)
+	assert.NotZero(t, found[0].Addr)
+	// hashes & addresses depend on the build, so are only checked for presence
+	for i, file := range found[0].Files {
+		if !file.Dir {
+			assert.NotEmpty(t, file.Hash, file.Name)
+			assert.NotZero(t, file.Addr, file.Name)
+		}
+		found[0].Files[i].Hash, found[0].Files[i].Addr = "", 0
+	}
+	assert.Equal(t, static.Files, found[0].Files)
+}
+
+func TestWriteText(t *testing.T) {
+	buf := new(bytes.Buffer)
+	require.NoError(t, embedfs.WriteText(buf, []embedfs.FS{static}))
+	expected := `main.static (4bb280)
+  static/
+  static/css/
+  static/index.html (12 bytes)
+  static/css/site.css (16 bytes)
+`
+	assert.Equal(t, expected, buf.String())
+}
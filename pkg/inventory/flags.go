@@ -0,0 +1,270 @@
+package inventory
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/nick-jones/gost/pkg/report"
+	"github.com/nick-jones/gost/pkg/scan"
+)
+
+// flagMethods are the functions & FlagSet methods of the standard flag package that define flags. Each takes the flag
+// name and usage as string arguments, with the string variants also taking the default value.
+var flagMethods = map[string]bool{
+	"Bool": true, "BoolVar": true, "BoolFunc": true,
+	"Duration": true, "DurationVar": true,
+	"Float64": true, "Float64Var": true,
+	"Func": true, "TextVar": true, "Var": true,
+	"Int": true, "IntVar": true, "Int64": true, "Int64Var": true,
+	"String": true, "StringVar": true,
+	"Uint": true, "UintVar": true, "Uint64": true, "Uint64Var": true,
+}
+
+// flagStringDefaults are the flag methods taking a string default value, which is passed between the name & usage
+var flagStringDefaults = map[string]bool{"String": true, "StringVar": true}
+
+// Field offsets of urfave/cli (v2) flag structs, such as cli.StringFlag. Every flag type shares the same leading
+// fields (Name, Category, DefaultText, FilePath, Usage, Required, Hidden, HasBeenSet) before Value. These match
+// v2.20.3, and the module version is not checked, so matching is best-effort: releases that change the layout go
+// unrecognised (or, at worst, have the wrong fields reported).
+const (
+	cliNameOffset  = 0
+	cliUsageOffset = 64
+	cliValueOffset = 88
+)
+
+// cliStringOffsets are the offsets of every string field of urfave/cli flag structs: Name, Category, DefaultText,
+// FilePath, Usage & Value (in the string flag types). A string stored at any other offset is not a flag.
+var cliStringOffsets = map[int64]bool{0: true, 16: true, 32: true, 48: true, 64: true, 88: true}
+
+// Flag is a command-line flag definition
+type Flag struct {
+	Name    string `json:"name"`
+	Default string `json:"default,omitempty"` // only known for string flags with a constant default
+	Usage   string `json:"usage,omitempty"`
+	Kind    string `json:"kind"` // how the flag is defined, e.g. flag.String, flag.(*FlagSet).String or cli.Flag
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+}
+
+// FlagGroup is the set of flags registered by a single function
+type FlagGroup struct {
+	Function string `json:"function"`
+	Flags    []Flag `json:"flags"`
+}
+
+// flagString is a string associated with a call or heap object that may define a flag
+type flagString struct {
+	value string
+	ref   scan.Reference
+}
+
+// Flags reconstructs the flags defined by the binary, grouped by registering function. Two sources are recognised:
+// strings passed to the standard flag package, ordered by the argument registers they are passed in, and strings
+// stored into urfave/cli flag structs, identified by their field offsets. Groups are ordered by function, and flags
+// by source location.
+func Flags(results []scan.Result) []FlagGroup {
+	calls := make(map[uint64][]flagString)   // standard flag package, keyed by call address
+	objects := make(map[string][]flagString) // urfave/cli struct literals, keyed by function & following call
+	for _, res := range results {
+		for _, ref := range res.Refs {
+			fs := flagString{value: res.Value, ref: ref}
+			switch {
+			case ref.CallAddr != 0 && isFlagMethod(ref.Callee):
+				calls[ref.CallAddr] = append(calls[ref.CallAddr], fs)
+			case ref.FieldOffset >= 0:
+				// fields of the same object are stored ahead of the next call (typically the next allocation)
+				key := fmt.Sprintf("%s@%x", ref.SymbolName, ref.CallAddr)
+				objects[key] = append(objects[key], fs)
+			}
+		}
+	}
+
+	// identical definitions (e.g. a shared flag whose constructor is inlined into several places) are listed once
+	byFunc := make(map[string][]Flag)
+	seen := make(map[string]map[Flag]bool)
+	add := func(fl Flag, fn string) {
+		if seen[fn] == nil {
+			seen[fn] = make(map[Flag]bool)
+		}
+		if !seen[fn][fl] {
+			seen[fn][fl] = true
+			byFunc[fn] = append(byFunc[fn], fl)
+		}
+	}
+	for _, strs := range calls {
+		if fl, fn, ok := stdFlag(strs); ok {
+			add(fl, fn)
+		}
+	}
+	for _, strs := range objects {
+		if fl, fn, ok := cliFlag(strs); ok {
+			add(fl, fn)
+		}
+	}
+
+	groups := make([]FlagGroup, 0, len(byFunc))
+	for fn, flags := range byFunc {
+		sort.Slice(flags, func(i, j int) bool {
+			if flags[i].File != flags[j].File {
+				return flags[i].File < flags[j].File
+			}
+			if flags[i].Line != flags[j].Line {
+				return flags[i].Line < flags[j].Line
+			}
+			return flags[i].Name < flags[j].Name
+		})
+		groups = append(groups, FlagGroup{Function: fn, Flags: flags})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Function < groups[j].Function
+	})
+	return groups
+}
+
+// isFlagMethod returns true if the callee is a flag defining function or FlagSet method of the flag package
+func isFlagMethod(callee string) bool {
+	return flagMethods[flagMethod(callee)]
+}
+
+// flagMethod returns the name of a function or FlagSet method of the flag package, or an empty string for any other
+// function
+func flagMethod(callee string) string {
+	switch {
+	case strings.HasPrefix(callee, "flag.(*FlagSet)."):
+		return strings.TrimPrefix(callee, "flag.(*FlagSet).")
+	case strings.HasPrefix(callee, "flag."):
+		return strings.TrimPrefix(callee, "flag.")
+	default:
+		return ""
+	}
+}
+
+// stdFlag builds a flag from the strings passed to a single flag package call. Strings passed in registers are the
+// name, default (string variants only) and usage, in argument order. Empty strings are never found, so each is
+// identified by its argument slot relative to the name: a string occupies 2 slots, so the default of the string
+// variants follows the name by 2, and the usage by 4. A string not passed in a register is the default stored into the
+// flag value, as seen when the value constructor is inlined into the call.
+func stdFlag(strs []flagString) (Flag, string, bool) {
+	var args, stored []flagString
+	for _, s := range strs {
+		if s.ref.ArgSlot >= 0 {
+			args = append(args, s)
+		} else {
+			stored = append(stored, s)
+		}
+	}
+	if len(args) == 0 {
+		return Flag{}, "", false
+	}
+	sort.Slice(args, func(i, j int) bool {
+		return args[i].ref.ArgSlot < args[j].ref.ArgSlot
+	})
+
+	first := args[0].ref
+	kind, file, line := flagCall(first)
+	fl := Flag{
+		Name: args[0].value,
+		Kind: kind,
+		File: file,
+		Line: line,
+	}
+	stringDefault := flagStringDefaults[flagMethod(first.Callee)]
+	for _, arg := range args[1:] {
+		switch slot := arg.ref.ArgSlot - first.ArgSlot; {
+		case !stringDefault:
+			fl.Usage = arg.value // other variants take no string between the name & usage
+		case slot == 2:
+			fl.Default = arg.value
+		case slot == 4:
+			fl.Usage = arg.value
+		}
+	}
+	if fl.Default == "" && len(stored) > 0 {
+		fl.Default = stored[0].value
+	}
+	return fl, first.SymbolName, true
+}
+
+// flagCall returns the flag package function called from outside of the package, along with the position of the call.
+// Package level functions (e.g. flag.String) are commonly inlined, leaving a FlagSet method as the callee, so the
+// inline stack (innermost first) is followed out to the first frame belonging to another package.
+func flagCall(ref scan.Reference) (string, string, int) {
+	kind := ref.Callee
+	for _, fr := range ref.Inlined {
+		if !strings.HasPrefix(fr.Function, "flag.") {
+			return kind, fr.File, fr.Line
+		}
+		kind = fr.Function
+	}
+	return kind, ref.File, ref.Line
+}
+
+// cliFlag builds a flag from the strings stored into a single heap object, provided they are stored at the offsets of
+// the name & usage fields of a urfave/cli flag struct, no strings are stored at offsets the struct has no string field
+// at, and the name is plausible. This rules out other objects that happen to hold strings at the same offsets, such as
+// arrays of small structs.
+func cliFlag(strs []flagString) (Flag, string, bool) {
+	fields := make(map[int64]flagString)
+	for _, s := range strs {
+		if !cliStringOffsets[s.ref.FieldOffset] {
+			return Flag{}, "", false
+		}
+		fields[s.ref.FieldOffset] = s
+	}
+	name, hasName := fields[cliNameOffset]
+	usage, hasUsage := fields[cliUsageOffset]
+	if !hasName || !hasUsage || !isFlagName(name.value) {
+		return Flag{}, "", false
+	}
+	return Flag{
+		Name:    name.value,
+		Default: fields[cliValueOffset].value,
+		Usage:   usage.value,
+		Kind:    "cli.Flag",
+		File:    name.ref.File,
+		Line:    name.ref.Line,
+	}, name.ref.SymbolName, true
+}
+
+// isFlagName returns true if the string could name a flag: letters, digits and the punctuation commonly found in flag
+// names, not starting with a dash (which is added when the flag is used)
+func isFlagName(s string) bool {
+	if s == "" || s[0] == '-' {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' && r != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteFlagsText writes each group as the function name followed by its flags, one per line
+func WriteFlagsText(w io.Writer, groups []FlagGroup) error {
+	for _, g := range groups {
+		if _, err := fmt.Fprintln(w, g.Function); err != nil {
+			return err
+		}
+		for _, fl := range g.Flags {
+			def := ""
+			if fl.Default != "" {
+				def = fmt.Sprintf(" (default %q)", fl.Default)
+			}
+			_, err := fmt.Fprintf(w, "  -%s%s: %s → %s:%d (%s)\n", fl.Name, def, fl.Usage, fl.File, fl.Line, fl.Kind)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteFlagsJSON writes the groups as a JSON document
+func WriteFlagsJSON(w io.Writer, groups []FlagGroup) error {
+	return report.WriteVersionedJSON(w, "groups", groups)
+}
@@ -0,0 +1,94 @@
+// Package inventory catalogues strings by the functions they are passed to, such as the names of environment variables
+// read by a binary.
+package inventory
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/nick-jones/gost/pkg/report"
+	"github.com/nick-jones/gost/pkg/scan"
+)
+
+// EnvCallees are the functions that read environment variables, taking the variable name as the first argument
+var EnvCallees = []string{
+	"os.Getenv",
+	"os.LookupEnv",
+	"syscall.Getenv",
+}
+
+// Item is a string passed to one of the functions of interest, along with every location it is passed from
+type Item struct {
+	Value     string     `json:"value"`
+	Locations []Location `json:"locations"`
+}
+
+// Location is where a string is passed to a function of interest
+type Location struct {
+	Callee string `json:"callee"`
+	Symbol string `json:"symbol,omitempty"`
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+}
+
+// String returns the location in the form file:line (symbol → callee)
+func (l Location) String() string {
+	return fmt.Sprintf("%s:%d (%s → %s)", l.File, l.Line, l.Symbol, l.Callee)
+}
+
+// ByCallee returns the strings passed to any of the supplied functions. Items are ordered by value, and locations by
+// file & line, so the result is deterministic.
+func ByCallee(results []scan.Result, callees ...string) []Item {
+	wanted := make(map[string]bool, len(callees))
+	for _, c := range callees {
+		wanted[c] = true
+	}
+
+	grouped := make(map[string][]Location)
+	for _, res := range results {
+		for _, ref := range res.Refs {
+			if !wanted[ref.Callee] {
+				continue
+			}
+			grouped[res.Value] = append(grouped[res.Value], Location{
+				Callee: ref.Callee,
+				Symbol: ref.SymbolName,
+				File:   ref.File,
+				Line:   ref.Line,
+			})
+		}
+	}
+
+	items := make([]Item, 0, len(grouped))
+	for value, locs := range grouped {
+		sort.Slice(locs, func(i, j int) bool {
+			if locs[i].File != locs[j].File {
+				return locs[i].File < locs[j].File
+			}
+			return locs[i].Line < locs[j].Line
+		})
+		items = append(items, Item{Value: value, Locations: locs})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Value < items[j].Value
+	})
+	return items
+}
+
+// WriteText writes one line per location, in the form value → file:line (symbol → callee)
+func WriteText(w io.Writer, items []Item) error {
+	for _, item := range items {
+		for _, loc := range item.Locations {
+			if _, err := fmt.Fprintf(w, "%s → %s\n", item.Value, loc); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the items as a JSON document
+func WriteJSON(w io.Writer, items []Item) error {
+	return report.WriteVersionedJSON(w, "items", items)
+}
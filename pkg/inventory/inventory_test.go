@@ -0,0 +1,193 @@
+package inventory_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nick-jones/gost/pkg/inventory"
+	"github.com/nick-jones/gost/pkg/scan"
+)
+
+func TestByCallee(t *testing.T) {
+	results := []scan.Result{
+		{Value: "PATH", Refs: []scan.Reference{
+			{SymbolName: "main.b", File: "b.go", Line: 4, Callee: "os.LookupEnv"},
+			{SymbolName: "main.a", File: "a.go", Line: 9, Callee: "os.Getenv"},
+		}},
+		{Value: "HOME", Refs: []scan.Reference{
+			{SymbolName: "main.a", File: "a.go", Line: 3, Callee: "os.Getenv"},
+		}},
+		{Value: "banana", Refs: []scan.Reference{
+			{SymbolName: "main.a", File: "a.go", Line: 5, Callee: "fmt.Println"},
+		}},
+	}
+
+	expected := []inventory.Item{
+		{Value: "HOME", Locations: []inventory.Location{
+			{Callee: "os.Getenv", Symbol: "main.a", File: "a.go", Line: 3},
+		}},
+		{Value: "PATH", Locations: []inventory.Location{
+			{Callee: "os.Getenv", Symbol: "main.a", File: "a.go", Line: 9},
+			{Callee: "os.LookupEnv", Symbol: "main.b", File: "b.go", Line: 4},
+		}},
+	}
+	assert.Equal(t, expected, inventory.ByCallee(results, inventory.EnvCallees...))
+}
+
+func TestFlags(t *testing.T) {
+	results := []scan.Result{
+		// flag.String("name", "world", "who to greet"), inlined so that the receiver occupies the first argument slot
+		{Value: "name", Refs: []scan.Reference{
+			{SymbolName: "main.main", File: "flag.go", Line: 899, Callee: "flag.(*FlagSet).String", CallAddr: 0x100, ArgSlot: 1, FieldOffset: -1, Inlined: []scan.Frame{
+				{Function: "flag.String", File: "flag.go", Line: 899},
+				{Function: "main.main", File: "main.go", Line: 9},
+			}},
+		}},
+		{Value: "world", Refs: []scan.Reference{
+			{SymbolName: "main.main", File: "main.go", Line: 9, Callee: "flag.(*FlagSet).String", CallAddr: 0x100, ArgSlot: 3, FieldOffset: -1},
+		}},
+		{Value: "who to greet", Refs: []scan.Reference{
+			{SymbolName: "main.main", File: "main.go", Line: 9, Callee: "flag.(*FlagSet).String", CallAddr: 0x100, ArgSlot: 5, FieldOffset: -1},
+		}},
+		// fs.Var(value, "dir", "working directory"), with the constructor of a value defaulting to "/tmp" inlined
+		{Value: "/tmp", Refs: []scan.Reference{
+			{SymbolName: "main.main", File: "main.go", Line: 11, Callee: "flag.(*FlagSet).Var", CallAddr: 0x200, ArgSlot: -1, FieldOffset: 0},
+		}},
+		{Value: "dir", Refs: []scan.Reference{
+			{SymbolName: "main.main", File: "main.go", Line: 11, Callee: "flag.(*FlagSet).Var", CallAddr: 0x200, ArgSlot: 3, FieldOffset: -1},
+		}},
+		{Value: "working directory", Refs: []scan.Reference{
+			{SymbolName: "main.main", File: "main.go", Line: 11, Callee: "flag.(*FlagSet).Var", CallAddr: 0x200, ArgSlot: 5, FieldOffset: -1},
+		}},
+		// &cli.StringFlag{Name: "format", Usage: "output format", Value: "text"}, constructed in 2 places
+		{Value: "format", Refs: []scan.Reference{
+			{SymbolName: "main.init", File: "cli.go", Line: 5, Callee: "runtime.newobject", CallAddr: 0x300, ArgSlot: -1, FieldOffset: 0},
+			{SymbolName: "main.init", File: "cli.go", Line: 5, Callee: "runtime.newobject", CallAddr: 0x400, ArgSlot: -1, FieldOffset: 0},
+		}},
+		{Value: "output format", Refs: []scan.Reference{
+			{SymbolName: "main.init", File: "cli.go", Line: 6, Callee: "runtime.newobject", CallAddr: 0x300, ArgSlot: -1, FieldOffset: 64},
+			{SymbolName: "main.init", File: "cli.go", Line: 6, Callee: "runtime.newobject", CallAddr: 0x400, ArgSlot: -1, FieldOffset: 64},
+		}},
+		{Value: "text", Refs: []scan.Reference{
+			{SymbolName: "main.init", File: "cli.go", Line: 7, Callee: "runtime.newobject", CallAddr: 0x300, ArgSlot: -1, FieldOffset: 88},
+			{SymbolName: "main.init", File: "cli.go", Line: 7, Callee: "runtime.newobject", CallAddr: 0x400, ArgSlot: -1, FieldOffset: 88},
+		}},
+		// []option{{Name: "adx"}, {Name: "aes"}, {Name: "erms"}, {Name: "fma"}}, an array of 32 byte structs
+		{Value: "adx", Refs: []scan.Reference{
+			{SymbolName: "internal/cpu.doinit", File: "cpu_x86.go", Line: 74, Callee: "runtime.growslice", CallAddr: 0x500, ArgSlot: -1, FieldOffset: 0},
+		}},
+		{Value: "aes", Refs: []scan.Reference{
+			{SymbolName: "internal/cpu.doinit", File: "cpu_x86.go", Line: 75, Callee: "runtime.growslice", CallAddr: 0x500, ArgSlot: -1, FieldOffset: 32},
+		}},
+		{Value: "erms", Refs: []scan.Reference{
+			{SymbolName: "internal/cpu.doinit", File: "cpu_x86.go", Line: 76, Callee: "runtime.growslice", CallAddr: 0x500, ArgSlot: -1, FieldOffset: 64},
+		}},
+		{Value: "fma", Refs: []scan.Reference{
+			{SymbolName: "internal/cpu.doinit", File: "cpu_x86.go", Line: 77, Callee: "runtime.growslice", CallAddr: 0x500, ArgSlot: -1, FieldOffset: 96},
+		}},
+	}
+
+	expected := []inventory.FlagGroup{
+		{Function: "main.init", Flags: []inventory.Flag{
+			{Name: "format", Default: "text", Usage: "output format", Kind: "cli.Flag", File: "cli.go", Line: 5},
+		}},
+		{Function: "main.main", Flags: []inventory.Flag{
+			{Name: "name", Default: "world", Usage: "who to greet", Kind: "flag.String", File: "main.go", Line: 9},
+			{Name: "dir", Default: "/tmp", Usage: "working directory", Kind: "flag.(*FlagSet).Var", File: "main.go", Line: 11},
+		}},
+	}
+	assert.Equal(t, expected, inventory.Flags(results))
+}
+
+func TestFlags_EmptyStrings(t *testing.T) {
+	// empty strings are not found, so only the slots of the others tell the default & usage apart
+	results := []scan.Result{
+		// fs.String("name", "", "who to greet")
+		{Value: "name", Refs: []scan.Reference{
+			{SymbolName: "main.main", File: "main.go", Line: 9, Callee: "flag.(*FlagSet).String", CallAddr: 0x100, ArgSlot: 1, FieldOffset: -1},
+		}},
+		{Value: "who to greet", Refs: []scan.Reference{
+			{SymbolName: "main.main", File: "main.go", Line: 9, Callee: "flag.(*FlagSet).String", CallAddr: 0x100, ArgSlot: 5, FieldOffset: -1},
+		}},
+		// fs.String("greeting", "hello", "")
+		{Value: "greeting", Refs: []scan.Reference{
+			{SymbolName: "main.main", File: "main.go", Line: 10, Callee: "flag.(*FlagSet).String", CallAddr: 0x200, ArgSlot: 1, FieldOffset: -1},
+		}},
+		{Value: "hello", Refs: []scan.Reference{
+			{SymbolName: "main.main", File: "main.go", Line: 10, Callee: "flag.(*FlagSet).String", CallAddr: 0x200, ArgSlot: 3, FieldOffset: -1},
+		}},
+		// fs.Bool("loud", false, "shout the greeting"), where the default occupies a single slot
+		{Value: "loud", Refs: []scan.Reference{
+			{SymbolName: "main.main", File: "main.go", Line: 11, Callee: "flag.(*FlagSet).Bool", CallAddr: 0x300, ArgSlot: 1, FieldOffset: -1},
+		}},
+		{Value: "shout the greeting", Refs: []scan.Reference{
+			{SymbolName: "main.main", File: "main.go", Line: 11, Callee: "flag.(*FlagSet).Bool", CallAddr: 0x300, ArgSlot: 4, FieldOffset: -1},
+		}},
+	}
+
+	expected := []inventory.FlagGroup{
+		{Function: "main.main", Flags: []inventory.Flag{
+			{Name: "name", Usage: "who to greet", Kind: "flag.(*FlagSet).String", File: "main.go", Line: 9},
+			{Name: "greeting", Default: "hello", Kind: "flag.(*FlagSet).String", File: "main.go", Line: 10},
+			{Name: "loud", Usage: "shout the greeting", Kind: "flag.(*FlagSet).Bool", File: "main.go", Line: 11},
+		}},
+	}
+	assert.Equal(t, expected, inventory.Flags(results))
+}
+
+func TestRoutes(t *testing.T) {
+	results := []scan.Result{
+		{Value: "GET example.com/items/{id}", Refs: []scan.Reference{
+			{SymbolName: "main.main", File: "main.go", Line: 11, Callee: "net/http.(*ServeMux).HandleFunc"},
+		}},
+		{Value: "/healthz", Refs: []scan.Reference{
+			{SymbolName: "main.main", File: "main.go", Line: 9, Callee: "net/http.HandleFunc"},
+		}},
+		{Value: "not a route", Refs: []scan.Reference{
+			{SymbolName: "main.main", File: "main.go", Line: 10, Callee: "net/http.Handle"},
+		}},
+		{Value: "/elsewhere", Refs: []scan.Reference{
+			{SymbolName: "main.main", File: "main.go", Line: 12, Callee: "fmt.Println"},
+		}},
+	}
+
+	expected := []inventory.Route{
+		{Pattern: "/healthz", Path: "/healthz", Mux: "DefaultServeMux", Function: "main.main", File: "main.go", Line: 9},
+		{
+			Pattern:  "GET example.com/items/{id}",
+			Method:   "GET",
+			Host:     "example.com",
+			Path:     "/items/{id}",
+			Mux:      "ServeMux",
+			Function: "main.main",
+			File:     "main.go",
+			Line:     11,
+		},
+	}
+	assert.Equal(t, expected, inventory.Routes(results))
+}
+
+func TestModules(t *testing.T) {
+	results := []scan.Result{
+		{Value: "x86asm", Refs: []scan.Reference{
+			{Module: "golang.org/x/arch", ModuleVersion: "v0.4.0"},
+			{Module: "golang.org/x/arch", ModuleVersion: "v0.4.0"},
+			{Module: scan.ModuleMain},
+		}},
+		{Value: "%d", Refs: []scan.Reference{
+			{Module: scan.ModuleStdlib},
+			{Module: "github.com/urfave/cli/v2", ModuleVersion: "v2.20.3"},
+		}},
+		{Value: "go:buildid", Refs: []scan.Reference{{}}},
+	}
+
+	expected := []inventory.ModuleSummary{
+		{Module: scan.ModuleMain, Strings: 1, References: 1},
+		{Module: "github.com/urfave/cli/v2", Version: "v2.20.3", Strings: 1, References: 1},
+		{Module: "golang.org/x/arch", Version: "v0.4.0", Strings: 1, References: 2},
+		{Module: scan.ModuleStdlib, Strings: 1, References: 1},
+		{Module: "unknown", Strings: 1, References: 1},
+	}
+	assert.Equal(t, expected, inventory.Modules(results))
+}
@@ -0,0 +1,85 @@
+package inventory
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/nick-jones/gost/pkg/report"
+	"github.com/nick-jones/gost/pkg/scan"
+)
+
+// moduleUnknown labels references whose module could not be determined (e.g. compiler generated code)
+const moduleUnknown = "unknown"
+
+// ModuleSummary counts the strings referenced from a single module
+type ModuleSummary struct {
+	Module     string `json:"module"` // module path, "stdlib", "main module" or "unknown"
+	Version    string `json:"version,omitempty"`
+	Strings    int    `json:"strings"`    // distinct strings referenced from the module
+	References int    `json:"references"` // references made from the module
+}
+
+// Modules summarises the strings referenced from each module. A string referenced from several modules is counted
+// against each. Summaries are ordered by module, with the main module first and the standard library last.
+func Modules(results []scan.Result) []ModuleSummary {
+	summaries := make(map[string]*ModuleSummary)
+	for _, res := range results {
+		seen := make(map[string]bool)
+		for _, ref := range res.Refs {
+			module := ref.Module
+			if module == "" {
+				module = moduleUnknown
+			}
+			summary, found := summaries[module]
+			if !found {
+				summary = &ModuleSummary{Module: module, Version: ref.ModuleVersion}
+				summaries[module] = summary
+			}
+			summary.References++
+			if !seen[module] {
+				seen[module] = true
+				summary.Strings++
+			}
+		}
+	}
+
+	sorted := make([]ModuleSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		sorted = append(sorted, *summary)
+	}
+	rank := func(module string) int {
+		switch module {
+		case scan.ModuleMain:
+			return 0
+		case scan.ModuleStdlib:
+			return 2
+		case moduleUnknown:
+			return 3
+		default:
+			return 1
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if ri, rj := rank(sorted[i].Module), rank(sorted[j].Module); ri != rj {
+			return ri < rj
+		}
+		return sorted[i].Module < sorted[j].Module
+	})
+	return sorted
+}
+
+// WriteModulesText writes a line per module, with columns aligned
+func WriteModulesText(w io.Writer, summaries []ModuleSummary) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for _, s := range summaries {
+		fmt.Fprintf(tw, "%s\t%s\t%d strings\t%d references\n", s.Module, s.Version, s.Strings, s.References)
+	}
+	return tw.Flush()
+}
+
+// WriteModulesJSON writes the summaries as a JSON document, listed under "modules"
+func WriteModulesJSON(w io.Writer, summaries []ModuleSummary) error {
+	return report.WriteVersionedJSON(w, "modules", summaries)
+}
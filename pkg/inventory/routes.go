@@ -0,0 +1,108 @@
+package inventory
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/nick-jones/gost/pkg/report"
+	"github.com/nick-jones/gost/pkg/scan"
+)
+
+// routeCallees maps the net/http functions that register handlers to the mux they register on
+var routeCallees = map[string]string{
+	"net/http.Handle":                  "DefaultServeMux",
+	"net/http.HandleFunc":              "DefaultServeMux",
+	"net/http.(*ServeMux).Handle":      "ServeMux",
+	"net/http.(*ServeMux).HandleFunc":  "ServeMux",
+	"net/http.(*ServeMux).register":    "ServeMux",
+	"net/http.(*ServeMux).registerErr": "ServeMux",
+}
+
+// Route is an HTTP handler pattern registered with a net/http ServeMux
+type Route struct {
+	Pattern  string `json:"pattern"`
+	Method   string `json:"method,omitempty"` // Go 1.22+ patterns only
+	Host     string `json:"host,omitempty"`
+	Path     string `json:"path"`
+	Mux      string `json:"mux"` // DefaultServeMux, or ServeMux for any other mux
+	Function string `json:"function"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+}
+
+// Routes returns the patterns registered with net/http, found from strings passed to the Handle & HandleFunc
+// functions and ServeMux methods. Patterns built by concatenation are reported as the constant part. Routes are
+// ordered by path, then by source location.
+func Routes(results []scan.Result) []Route {
+	routes := make([]Route, 0)
+	for _, res := range results {
+		for _, ref := range res.Refs {
+			mux, found := routeCallees[ref.Callee]
+			if !found {
+				continue
+			}
+			method, host, path, ok := parsePattern(res.Value)
+			if !ok {
+				continue
+			}
+			routes = append(routes, Route{
+				Pattern:  res.Value,
+				Method:   method,
+				Host:     host,
+				Path:     path,
+				Mux:      mux,
+				Function: ref.SymbolName,
+				File:     ref.File,
+				Line:     ref.Line,
+			})
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		a, b := routes[i], routes[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.Method != b.Method {
+			return a.Method < b.Method
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	return routes
+}
+
+// parsePattern splits a ServeMux pattern of the form [METHOD ][HOST]/[PATH]. Strings that are not valid patterns are
+// reported as not ok, which weeds out other strings passed to the same functions (e.g. handler constructors).
+func parsePattern(pattern string) (method, host, path string, ok bool) {
+	rest := pattern
+	if i := strings.IndexAny(rest, " \t"); i >= 0 {
+		method, rest = rest[:i], strings.TrimLeft(rest[i:], " \t")
+		if method == "" || strings.ToUpper(method) != method {
+			return "", "", "", false
+		}
+	}
+	i := strings.Index(rest, "/")
+	if i < 0 {
+		return "", "", "", false
+	}
+	return method, rest[:i], rest[i:], true
+}
+
+// WriteRoutesText writes one line per route, in the form pattern [mux] → file:line (function)
+func WriteRoutesText(w io.Writer, routes []Route) error {
+	for _, r := range routes {
+		if _, err := fmt.Fprintf(w, "%s [%s] → %s:%d (%s)\n", r.Pattern, r.Mux, r.File, r.Line, r.Function); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteRoutesJSON writes the routes as a JSON document
+func WriteRoutesJSON(w io.Writer, routes []Route) error {
+	return report.WriteVersionedJSON(w, "routes", routes)
+}
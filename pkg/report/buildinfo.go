@@ -0,0 +1,63 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/nick-jones/gost/pkg/scan"
+)
+
+// BuildInfo is the JSON document describing the build information of a binary. Settings are keyed by name, e.g.
+// "GOOS", "CGO_ENABLED", "-trimpath" or "vcs.revision".
+type BuildInfo struct {
+	Version   int               `json:"version"`
+	GoVersion string            `json:"go_version"`
+	Path      string            `json:"path,omitempty"`
+	Main      Module            `json:"main"`
+	Deps      []Module          `json:"deps"`
+	Settings  map[string]string `json:"settings"`
+}
+
+// Module is a module that contributed to the build
+type Module struct {
+	Path    string  `json:"path"`
+	Version string  `json:"version,omitempty"`
+	Sum     string  `json:"sum,omitempty"`
+	Replace *Module `json:"replace,omitempty"`
+}
+
+// NewBuildInfo converts build information into a document
+func NewBuildInfo(bi *scan.BuildInfo) BuildInfo {
+	doc := BuildInfo{
+		Version:   SchemaVersion,
+		GoVersion: bi.GoVersion,
+		Path:      bi.Path,
+		Main:      newModule(bi.Main),
+		Deps:      make([]Module, len(bi.Deps)),
+		Settings:  make(map[string]string, len(bi.Settings)),
+	}
+	for i, dep := range bi.Deps {
+		doc.Deps[i] = newModule(dep)
+	}
+	for _, s := range bi.Settings {
+		doc.Settings[s.Key] = s.Value
+	}
+	return doc
+}
+
+// newModule converts a single module
+func newModule(m scan.Module) Module {
+	mod := Module{Path: m.Path, Version: m.Version, Sum: m.Sum}
+	if m.Replace != nil {
+		replace := newModule(*m.Replace)
+		mod.Replace = &replace
+	}
+	return mod
+}
+
+// WriteBuildInfoJSON writes the build information as an indented JSON document
+func WriteBuildInfoJSON(w io.Writer, bi *scan.BuildInfo) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(NewBuildInfo(bi))
+}
@@ -0,0 +1,214 @@
+// Package report defines the machine readable representation of scan results. The schema is versioned independently
+// of the scan package, so consumers can rely on it remaining stable between releases.
+//
+// A JSON document takes the following form:
+//
+//	{
+//	  "version": 1,
+//	  "results": [
+//	    {
+//	      "address": "0x49b000",
+//	      "value": "banana",
+//	      "references": [
+//	        {
+//	          "address": "0x4a1b2c",
+//	          "symbol": "main.main",
+//	          "offset": 28,
+//	          "file": "/src/main.go",
+//	          "line": 6,
+//	          "callee": "fmt.Println",
+//	          "module": "main module",
+//	          "scope": "main"
+//	        }
+//	      ]
+//	    }
+//	  ]
+//	}
+//
+// NDJSON output carries one result object per line, each with a version field of its own.
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nick-jones/gost/pkg/scan"
+)
+
+// SchemaVersion is the version of the schema emitted. It is incremented whenever a change would break consumers.
+const SchemaVersion = 1
+
+// Document is the top level JSON document
+type Document struct {
+	Version int      `json:"version"`
+	Results []Result `json:"results"`
+}
+
+// Result is a single located string
+type Result struct {
+	Version int         `json:"version,omitempty"` // only populated in NDJSON output
+	Address Address     `json:"address"`
+	Value   string      `json:"value"`
+	Refs    []Reference `json:"references"`
+}
+
+// Reference is a single reference to a string
+type Reference struct {
+	Address Address `json:"address"`
+	Symbol  string  `json:"symbol,omitempty"`
+	Offset  int     `json:"offset"`
+	File    string  `json:"file,omitempty"`
+	Line    int     `json:"line,omitempty"`
+	Callee  string  `json:"callee,omitempty"`
+	// Module is the module containing the file: a module path, "stdlib" or "main module"
+	Module        string `json:"module,omitempty"`
+	ModuleVersion string `json:"module_version,omitempty"`
+	Scope         string `json:"scope,omitempty"` // "main", "deps" or "std"
+	// Inlined is the inline stack, innermost first and ending with the physical function, if the reference is within
+	// inlined code
+	Inlined []Frame `json:"inlined,omitempty"`
+	// Target is the variable or field the string is assigned to (e.g. cfg.Endpoint), and TargetType the struct type
+	// holding that field (e.g. main.Config). These require DWARF, so are absent for stripped binaries.
+	Target     string `json:"target,omitempty"`
+	TargetType string `json:"target_type,omitempty"`
+}
+
+// Frame is a function within an inline stack
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+}
+
+// Address is encoded as a hexadecimal string, since JSON numbers cannot reliably represent 64-bit values
+type Address uint64
+
+// MarshalText encodes the address as a 0x prefixed hexadecimal string
+func (a Address) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("0x%x", uint64(a))), nil
+}
+
+// UnmarshalText decodes a 0x prefixed hexadecimal string
+func (a *Address) UnmarshalText(text []byte) error {
+	s := string(text)
+	if !strings.HasPrefix(s, "0x") {
+		return fmt.Errorf("invalid address %q: missing 0x prefix", s)
+	}
+	v, err := strconv.ParseUint(s[2:], 16, 64)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %w", s, err)
+	}
+	*a = Address(v)
+	return nil
+}
+
+// New converts scan results into a document. Results are ordered by address, as are the references of each result,
+// so the document is deterministic for a given binary.
+func New(results []scan.Result) Document {
+	doc := Document{
+		Version: SchemaVersion,
+		Results: make([]Result, len(results)),
+	}
+	for i, res := range results {
+		doc.Results[i] = newResult(res)
+	}
+	sort.SliceStable(doc.Results, func(i, j int) bool {
+		return doc.Results[i].Address < doc.Results[j].Address
+	})
+	return doc
+}
+
+// newResult converts a single scan result
+func newResult(res scan.Result) Result {
+	refs := make([]Reference, len(res.Refs))
+	for i, ref := range res.Refs {
+		refs[i] = Reference{
+			Address:       Address(ref.Addr),
+			Symbol:        ref.SymbolName,
+			Offset:        ref.SymbolOffset,
+			File:          ref.File,
+			Line:          ref.Line,
+			Callee:        ref.Callee,
+			Module:        ref.Module,
+			ModuleVersion: ref.ModuleVersion,
+			Scope:         string(ref.Scope),
+			Target:        ref.Target,
+			TargetType:    ref.TargetType,
+		}
+		for _, fr := range ref.Inlined {
+			refs[i].Inlined = append(refs[i].Inlined, Frame{Function: fr.Function, File: fr.File, Line: fr.Line})
+		}
+	}
+	sort.SliceStable(refs, func(i, j int) bool {
+		return refs[i].Address < refs[j].Address
+	})
+	return Result{
+		Address: Address(res.Addr),
+		Value:   res.Value,
+		Refs:    refs,
+	}
+}
+
+// WriteJSON writes the results as a single indented JSON document
+func WriteJSON(w io.Writer, results []scan.Result) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(New(results))
+}
+
+// WriteNDJSON writes the results as newline delimited JSON, one result per line
+func WriteNDJSON(w io.Writer, results []scan.Result) error {
+	enc := json.NewEncoder(w)
+	for _, res := range New(results).Results {
+		res.Version = SchemaVersion
+		if err := enc.Encode(res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteVersionedJSON writes an indented JSON document carrying the schema version, followed by the value under the
+// supplied key. The other subcommands share the schema version of scan results, so they are versioned together. If the
+// key is empty, the value must encode as a non-empty object, whose fields are placed alongside the version instead.
+func WriteVersionedJSON(w io.Writer, key string, v interface{}) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	doc := []byte(fmt.Sprintf(`{"version":%d,`, SchemaVersion))
+	if key == "" {
+		if len(value) < 3 || value[0] != '{' {
+			return fmt.Errorf("cannot inline %s alongside the version", value)
+		}
+		doc = append(doc, value[1:]...)
+	} else {
+		name, _ := json.Marshal(key)
+		doc = append(append(append(append(doc, name...), ':'), value...), '}')
+	}
+
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, doc, "", "  "); err != nil {
+		return err
+	}
+	buf.WriteByte('\n')
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// ReadJSON decodes a document previously written by WriteJSON
+func ReadJSON(r io.Reader) (Document, error) {
+	var doc Document
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return Document{}, err
+	}
+	if doc.Version != SchemaVersion {
+		return Document{}, fmt.Errorf("unsupported schema version %d", doc.Version)
+	}
+	return doc, nil
+}
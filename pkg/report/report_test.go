@@ -0,0 +1,87 @@
+package report_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nick-jones/gost/pkg/report"
+	"github.com/nick-jones/gost/pkg/scan"
+)
+
+var results = []scan.Result{
+	{
+		Addr:  0x49b010,
+		Value: "apple",
+		Refs: []scan.Reference{
+			{Addr: 0x401020, SymbolName: "main.main", SymbolOffset: 32, File: "main.go", Line: 7},
+			{Addr: 0x401010, SymbolName: "main.main", SymbolOffset: 16, File: "main.go", Line: 6},
+		},
+	},
+	{
+		Addr:  0x49b000,
+		Value: "banana",
+	},
+}
+
+func TestWriteJSON(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, report.WriteJSON(buf, results))
+
+	doc, err := report.ReadJSON(buf)
+	require.NoError(t, err)
+
+	expected := report.Document{
+		Version: report.SchemaVersion,
+		Results: []report.Result{
+			{
+				Address: 0x49b000,
+				Value:   "banana",
+				Refs:    []report.Reference{},
+			},
+			{
+				Address: 0x49b010,
+				Value:   "apple",
+				Refs: []report.Reference{
+					{Address: 0x401010, Symbol: "main.main", Offset: 16, File: "main.go", Line: 6},
+					{Address: 0x401020, Symbol: "main.main", Offset: 32, File: "main.go", Line: 7},
+				},
+			},
+		},
+	}
+	assert.Equal(t, expected, doc)
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, report.WriteNDJSON(buf, results))
+
+	expected := `{"version":1,"address":"0x49b000","value":"banana","references":[]}
+{"version":1,"address":"0x49b010","value":"apple","references":[` +
+		`{"address":"0x401010","symbol":"main.main","offset":16,"file":"main.go","line":6},` +
+		`{"address":"0x401020","symbol":"main.main","offset":32,"file":"main.go","line":7}]}
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestReadJSON_UnsupportedVersion(t *testing.T) {
+	_, err := report.ReadJSON(bytes.NewBufferString(`{"version":99,"results":[]}`))
+	assert.Error(t, err)
+}
+
+func TestWriteVersionedJSON(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, report.WriteVersionedJSON(buf, "items", []string{"banana"}))
+	assert.Equal(t, "{\n  \"version\": 1,\n  \"items\": [\n    \"banana\"\n  ]\n}\n", buf.String())
+
+	// without a key, the fields are placed alongside the version
+	buf.Reset()
+	require.NoError(t, report.WriteVersionedJSON(buf, "", struct {
+		Added []string `json:"added"`
+	}{Added: []string{"apple"}}))
+	assert.Equal(t, "{\n  \"version\": 1,\n  \"added\": [\n    \"apple\"\n  ]\n}\n", buf.String())
+
+	assert.Error(t, report.WriteVersionedJSON(&bytes.Buffer{}, "", []string{"banana"}))
+}
@@ -0,0 +1,170 @@
+package scan
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"unicode"
+
+	"github.com/nick-jones/gost/internal/exe"
+)
+
+// BuildInfo is the build information embedded by the Go linker
+type BuildInfo struct {
+	GoVersion string         // toolchain that built the binary, e.g. go1.21.3
+	Path      string         // import path of the main package
+	Main      Module         // module containing the main package
+	Deps      []Module       // modules the binary depends upon
+	Settings  []BuildSetting // e.g. GOOS, GOARCH, CGO_ENABLED, -trimpath, vcs.revision, vcs.time
+
+	needles []moduleNeedle
+}
+
+// Module describes a module that contributed to the build
+type Module struct {
+	Path    string  // module path, e.g. golang.org/x/net
+	Version string  // module version, e.g. v0.17.0
+	Sum     string  // checksum
+	Replace *Module // replacement, if any
+}
+
+// BuildSetting is a key & value pair describing a setting that influenced the build
+type BuildSetting struct {
+	Key   string
+	Value string
+}
+
+// moduleNeedle is a fragment of source file paths that identifies a module
+type moduleNeedle struct {
+	fragment string
+	prefix   bool // whether the fragment must prefix the path, rather than appear anywhere within it
+	module   Module
+}
+
+// ReadBuildInfo reads the build information embedded in the binary. Binaries built without module support carry
+// none, in which case an error is returned.
+func ReadBuildInfo(r io.ReaderAt) (*BuildInfo, error) {
+	f, err := exe.New(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file: %w", err)
+	}
+	bi, err := f.BuildInfo()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read build info: %w", err)
+	}
+	return newBuildInfo(bi), nil
+}
+
+// newBuildInfo converts build information, as decoded by the runtime/debug package
+func newBuildInfo(bi *debug.BuildInfo) *BuildInfo {
+	info := &BuildInfo{
+		GoVersion: bi.GoVersion,
+		Path:      bi.Path,
+		Main:      newModule(&bi.Main),
+	}
+	for _, dep := range bi.Deps {
+		info.Deps = append(info.Deps, newModule(dep))
+	}
+	for _, s := range bi.Settings {
+		info.Settings = append(info.Settings, BuildSetting{Key: s.Key, Value: s.Value})
+	}
+
+	// Dependencies are found in the module cache (or beneath the module path, if -trimpath is used) at path@version,
+	// or beneath vendor/. Files of the main module only carry its path if -trimpath is used.
+	for _, dep := range info.Deps {
+		src := dep
+		if dep.Replace != nil {
+			src = *dep.Replace
+		}
+		if src.Version != "" {
+			info.needles = append(info.needles, moduleNeedle{fragment: escapePath(src.Path) + "@" + escapePath(src.Version) + "/", module: dep})
+		} else if filepath.IsAbs(src.Path) {
+			info.needles = append(info.needles, moduleNeedle{fragment: filepath.ToSlash(src.Path) + "/", prefix: true, module: dep})
+		}
+		info.needles = append(info.needles, moduleNeedle{fragment: "/vendor/" + dep.Path + "/", module: dep})
+	}
+	if info.Main.Path != "" {
+		info.needles = append(info.needles, moduleNeedle{fragment: info.Main.Path + "/", prefix: true, module: info.Main})
+	}
+	return info
+}
+
+// newModule converts a module, as decoded by the runtime/debug package
+func newModule(m *debug.Module) Module {
+	mod := Module{Path: m.Path, Version: m.Version, Sum: m.Sum}
+	if m.Replace != nil {
+		replace := newModule(m.Replace)
+		mod.Replace = &replace
+	}
+	return mod
+}
+
+// escapePath applies the module cache's case encoding, where upper case letters are replaced by ! and the lower case
+// letter (as the cache may reside on a case-insensitive file system)
+func escapePath(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		if unicode.IsUpper(r) {
+			b.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Setting returns the value of a build setting, or an empty string if it is not set
+func (b *BuildInfo) Setting(key string) string {
+	for _, s := range b.Settings {
+		if s.Key == key {
+			return s.Value
+		}
+	}
+	return ""
+}
+
+// ModuleForFile returns the module a source file (e.g. Reference.File) belongs to. Dependencies are identified by
+// their location in the module cache or vendor directory. Files of the main module can only be identified if the
+// binary was built with -trimpath, and standard library files are never matched.
+func (b *BuildInfo) ModuleForFile(file string) (Module, bool) {
+	n, _ := b.matchFile(filepath.ToSlash(file))
+	if n == nil {
+		return Module{}, false
+	}
+	return n.module, true
+}
+
+// matchFile returns the needle identifying the module a file belongs to, along with the offset of its fragment within
+// the file, or nil if there is none
+func (b *BuildInfo) matchFile(file string) (*moduleNeedle, int) {
+	var (
+		best *moduleNeedle
+		at   int
+	)
+	for i, n := range b.needles {
+		if best != nil && len(n.fragment) <= len(best.fragment) {
+			continue // nested module paths are possible, so the most specific match wins
+		}
+		if idx := strings.Index(file, n.fragment); n.prefix && idx == 0 || !n.prefix && idx >= 0 {
+			best, at = &b.needles[i], idx
+		}
+	}
+	return best, at
+}
+
+// trimFile returns a file of a dependency (or of the main module, if built with -trimpath) as -trimpath records it:
+// the module path & version (the main module has no version), followed by the path within the module
+func (b *BuildInfo) trimFile(file string) (string, bool) {
+	file = filepath.ToSlash(file)
+	n, at := b.matchFile(file)
+	if n == nil {
+		return "", false
+	}
+	rest := file[at+len(n.fragment):]
+	if n.module.Version == "" || n.module.Path == b.Main.Path {
+		return n.module.Path + "/" + rest, true
+	}
+	return n.module.Path + "@" + n.module.Version + "/" + rest, true
+}
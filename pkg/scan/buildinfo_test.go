@@ -0,0 +1,80 @@
+package scan_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nick-jones/gost/pkg/scan"
+)
+
+// readOwnBuildInfo reads the build information of the test binary, which depends upon testify
+func readOwnBuildInfo(t *testing.T) *scan.BuildInfo {
+	path, err := os.Executable()
+	require.NoError(t, err)
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	bi, err := scan.ReadBuildInfo(f)
+	require.NoError(t, err)
+	return bi
+}
+
+func TestReadBuildInfo(t *testing.T) {
+	bi := readOwnBuildInfo(t)
+	assert.Equal(t, "github.com/nick-jones/gost", bi.Main.Path)
+	assert.NotEmpty(t, bi.GoVersion)
+	assert.NotEmpty(t, bi.Setting("GOOS"))
+}
+
+func TestBuildInfo_ModuleForFile(t *testing.T) {
+	bi := readOwnBuildInfo(t)
+	var testify scan.Module
+	for _, dep := range bi.Deps {
+		if dep.Path == "github.com/stretchr/testify" {
+			testify = dep
+		}
+	}
+	require.NotEmpty(t, testify.Version)
+
+	testCases := []struct {
+		name     string
+		file     string
+		expected string
+	}{
+		{
+			name:     "module cache",
+			file:     "/root/go/pkg/mod/github.com/stretchr/testify@" + testify.Version + "/assert/assertions.go",
+			expected: "github.com/stretchr/testify",
+		},
+		{
+			name:     "trimpath dependency",
+			file:     "github.com/stretchr/testify@" + testify.Version + "/assert/assertions.go",
+			expected: "github.com/stretchr/testify",
+		},
+		{
+			name:     "vendored",
+			file:     "/src/app/vendor/github.com/stretchr/testify/assert/assertions.go",
+			expected: "github.com/stretchr/testify",
+		},
+		{
+			name:     "trimpath main module",
+			file:     "github.com/nick-jones/gost/pkg/scan/run.go",
+			expected: "github.com/nick-jones/gost",
+		},
+		{
+			name: "standard library",
+			file: "/usr/local/go/src/fmt/print.go",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mod, found := bi.ModuleForFile(tc.file)
+			assert.Equal(t, tc.expected != "", found)
+			assert.Equal(t, tc.expected, mod.Path)
+		})
+	}
+}
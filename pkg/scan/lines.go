@@ -0,0 +1,63 @@
+package scan
+
+import (
+	"fmt"
+
+	"github.com/nick-jones/gost/internal/exe"
+)
+
+// LineSource selects where the file & line of each reference are read from
+type LineSource string
+
+// Sources of file & line information
+const (
+	LinesBest  LineSource = "best"    // the DWARF line table where it covers the address, otherwise the PCLN table
+	LinesPCLN  LineSource = "pclntab" // the PCLN table, which is present even in stripped binaries
+	LinesDWARF LineSource = "dwarf"   // the DWARF line table (.debug_line), which is omitted by -ldflags -w
+)
+
+// ParseLineSource parses the name of a line source
+func ParseLineSource(s string) (LineSource, error) {
+	switch src := LineSource(s); src {
+	case LinesBest, LinesPCLN, LinesDWARF:
+		return src, nil
+	default:
+		return "", fmt.Errorf("invalid line source: %s", s)
+	}
+}
+
+// lineResolver returns the file & line of an instruction, or an empty file & 0 if unknown
+type lineResolver func(pc uint64) (string, int)
+
+// newLineResolver resolves positions from the requested source. When the best source is requested, binaries without
+// DWARF silently fall back to the PCLN table, whereas requesting DWARF specifically fails.
+func newLineResolver(f *exe.File, src LineSource) (lineResolver, error) {
+	symtab, err := f.PCLNTable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create symtab: %w", err)
+	}
+	pcln := func(pc uint64) (string, int) {
+		file, line, _ := symtab.PCToLine(pc)
+		return file, line
+	}
+	if src == LinesPCLN {
+		return pcln, nil
+	}
+
+	lines, err := f.DWARFLineTable()
+	switch {
+	case err != nil && src == LinesDWARF:
+		return nil, fmt.Errorf("failed to read DWARF line table: %w", err)
+	case err != nil:
+		return pcln, nil
+	}
+	return func(pc uint64) (string, int) {
+		if file, line, ok := lines.PCToLine(pc); ok {
+			return file, line
+		}
+		if src == LinesDWARF {
+			return "", 0
+		}
+		return pcln(pc)
+	}, nil
+}
@@ -0,0 +1,138 @@
+package scan
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/nick-jones/gost/internal/exe"
+)
+
+// Modules assigned to references that do not belong to a dependency
+const (
+	ModuleStdlib = "stdlib"
+	ModuleMain   = "main module"
+)
+
+// moduleResolver determines which module source files belong to
+type moduleResolver struct {
+	bi       *BuildInfo // nil if the binary carries no build information
+	goroot   string     // directory the standard library was compiled from, including the trailing separator
+	trim     bool       // whether paths were trimmed (-trimpath), making standard library paths relative
+	mainRoot string     // directory of the main module, including the trailing separator (if known)
+}
+
+// newModuleResolver prepares a resolver for the binary. The location of the standard library is determined from the
+// file of runtime.main, which every binary contains. The location of the main module is determined from the file of
+// main.main, whose package lies at the main package path within the module.
+func newModuleResolver(f *exe.File) *moduleResolver {
+	r := &moduleResolver{}
+	if bi, err := f.BuildInfo(); err == nil {
+		r.bi = newBuildInfo(bi)
+	}
+	tab, err := f.PCLNTable()
+	if err != nil {
+		return r
+	}
+	if fn := tab.LookupFunc("runtime.main"); fn != nil {
+		file, _, _ := tab.PCToLine(fn.Entry)
+		if i := strings.LastIndex(file, "runtime/"); i >= 0 {
+			r.goroot = file[:i]
+			r.trim = r.goroot == ""
+		}
+	}
+	if fn := tab.LookupFunc("main.main"); fn != nil && r.bi != nil {
+		file, _, _ := tab.PCToLine(fn.Entry)
+		dir := path.Dir(filepath.ToSlash(file)) + "/"
+		if pkg := strings.TrimPrefix(r.bi.Path, r.bi.Main.Path) + "/"; strings.HasSuffix(dir, pkg) {
+			r.mainRoot = strings.TrimSuffix(dir, pkg) + "/"
+		}
+	}
+	return r
+}
+
+// resolve returns the module (and version) a source file belongs to. An empty module is returned if this cannot be
+// determined, such as for compiler generated code.
+func (r *moduleResolver) resolve(file string) (string, string) {
+	if file == "" || strings.HasPrefix(file, "<") {
+		return "", "" // e.g. <autogenerated>
+	}
+	// checked first, since the standard library vendors modules of its own
+	if r.goroot != "" && strings.HasPrefix(file, r.goroot) {
+		return ModuleStdlib, ""
+	}
+	if r.bi != nil {
+		if mod, found := r.bi.ModuleForFile(file); found {
+			if mod.Path == r.bi.Main.Path {
+				return ModuleMain, mod.Version
+			}
+			return mod.Path, mod.Version
+		}
+	}
+	// with -trimpath, standard library paths are relative to GOROOT/src, so start with a package path lacking a dot
+	if r.trim && !filepath.IsAbs(file) && !strings.Contains(strings.SplitN(file, "/", 2)[0], ".") {
+		return ModuleStdlib, ""
+	}
+	if r.bi != nil {
+		// anything remaining lies outside of the module cache & GOROOT, so must be the main module
+		return ModuleMain, r.bi.Main.Version
+	}
+	return "", ""
+}
+
+// trimPath returns a file as -trimpath would record it, so that builds made in different directories (or with and
+// without -trimpath) can be compared. Files of the main module are prefixed with its path, those of dependencies with
+// their path & version, and those of the standard library are relative to GOROOT/src. Anything else is returned as it
+// is.
+func (r *moduleResolver) trimPath(file string) string {
+	switch mod, _ := r.resolve(file); {
+	case mod == ModuleStdlib:
+		return strings.TrimPrefix(file, r.goroot)
+	case mod == ModuleMain && r.mainRoot != "" && strings.HasPrefix(filepath.ToSlash(file), r.mainRoot):
+		return path.Join(r.bi.Main.Path, strings.TrimPrefix(filepath.ToSlash(file), r.mainRoot))
+	case r.bi != nil:
+		if trimmed, ok := r.bi.trimFile(file); ok {
+			return trimmed
+		}
+	}
+	return file
+}
+
+// enrichWithModules tags each reference with the module of the code it is made from, and the scope of that code. Where
+// the reference is within inlined code, the physical function (the outermost frame) makes the reference, so an
+// application's string passed to an inlined standard library function (e.g. flag.String) remains the application's.
+func enrichWithModules(results []Result, f *exe.File) []Result {
+	resolver := newModuleResolver(f)
+	for i := range results {
+		for j := range results[i].Refs {
+			ref := &results[i].Refs[j]
+			file := ref.File
+			if n := len(ref.Inlined); n > 0 {
+				file = ref.Inlined[n-1].File
+			}
+			ref.Module, ref.ModuleVersion = resolver.resolve(file)
+			ref.TrimmedFile = resolver.trimPath(ref.File)
+			ref.Scope = resolver.scope(*ref)
+		}
+	}
+	return results
+}
+
+// filterReferences returns the results with references satisfying the supplied function, retaining only those
+// references
+func filterReferences(results []Result, keep func(Reference) bool) []Result {
+	filtered := make([]Result, 0)
+	for _, res := range results {
+		refs := make([]Reference, 0)
+		for _, ref := range res.Refs {
+			if keep(ref) {
+				refs = append(refs, ref)
+			}
+		}
+		if len(refs) > 0 {
+			res.Refs = refs
+			filtered = append(filtered, res)
+		}
+	}
+	return filtered
+}
@@ -1,9 +1,16 @@
 package scan
 
+import "io"
+
 type RunOptions struct {
 	stringTableIgnore bool
 	stringTableGuess  bool
 	permitNulls       bool
+	module            string
+	scopes            map[Scope]bool
+	lineSource        LineSource
+	debugDir          string
+	warnings          io.Writer
 }
 
 type Option func(*RunOptions)
@@ -25,3 +32,47 @@ func WithNullsPermitted() Option {
 		o.permitNulls = true
 	}
 }
+
+// WithModule restricts results to strings referenced from the supplied module (a module path, ModuleStdlib or
+// ModuleMain). Only the references from that module are retained.
+func WithModule(module string) Option {
+	return func(o *RunOptions) {
+		o.module = module
+	}
+}
+
+// WithScope restricts results to strings referenced from code within the supplied scopes, e.g. WithScope(ScopeMain)
+// for the application's own strings. Only the references from those scopes are retained.
+func WithScope(scopes ...Scope) Option {
+	return func(o *RunOptions) {
+		if o.scopes == nil {
+			o.scopes = make(map[Scope]bool)
+		}
+		for _, s := range scopes {
+			o.scopes[s] = true
+		}
+	}
+}
+
+// WithLineSource selects where the file & line of each reference are read from. LinesPCLN is used by default.
+func WithLineSource(src LineSource) Option {
+	return func(o *RunOptions) {
+		o.lineSource = src
+	}
+}
+
+// WithDebugDir supplies a directory holding separate debug files for stripped ELF binaries. Symbols & DWARF are read
+// from the debug file matching the binary, located by build ID or .gnu_debuglink.
+func WithDebugDir(dir string) Option {
+	return func(o *RunOptions) {
+		o.debugDir = dir
+	}
+}
+
+// WithWarnings supplies a writer for non-fatal problems, such as a debug directory holding no debug file matching the
+// binary
+func WithWarnings(w io.Writer) Option {
+	return func(o *RunOptions) {
+		o.warnings = w
+	}
+}
@@ -2,14 +2,15 @@ package scan
 
 import (
 	"bytes"
-	"debug/gosym"
 	"fmt"
 	"io"
 	"sort"
 
 	"github.com/nick-jones/gost/internal/address"
 	"github.com/nick-jones/gost/internal/analysis"
+	"github.com/nick-jones/gost/internal/dwarfvar"
 	"github.com/nick-jones/gost/internal/exe"
+	"github.com/nick-jones/gost/internal/inline"
 	"github.com/nick-jones/gost/internal/strtable"
 )
 
@@ -22,11 +23,29 @@ type Result struct {
 
 // References carries information relating to a reference to a string
 type Reference struct {
-	Addr         uint64 // address where the reference is made
-	SymbolName   string // closest symbol
-	SymbolOffset int    // offset from the closes symbol
-	File         string // file that contains the reference
-	Line         int    // line number of the above file
+	Addr          uint64  // address where the reference is made
+	SymbolName    string  // closest symbol
+	SymbolOffset  int     // offset from the closes symbol
+	Callee        string  // function the string appears to be passed to (if known)
+	CallAddr      uint64  // address of the call to the above function (if known)
+	ArgSlot       int     // register argument slot the string is passed in, or -1 if not passed in a register
+	FieldOffset   int64   // offset of the heap object field the string is stored in, or -1 if not stored in one
+	Module        string  // module containing the physical function's file (ModuleStdlib or ModuleMain if not a dependency), if known
+	ModuleVersion string  // version of the above module, if known
+	Scope         Scope   // whether the reference is made from the main module, a dependency or the standard library
+	File          string  // file that contains the reference
+	Line          int     // line number of the above file
+	TrimmedFile   string  // the above file as -trimpath would record it, for comparison across build directories
+	Inlined       []Frame // inline stack, innermost first and ending with the physical function, if the reference is within inlined code
+	Target        string  // variable or field the string is assigned to, e.g. cfg.Endpoint (if known)
+	TargetType    string  // struct type holding the above field, e.g. main.Config (if the target is a field)
+}
+
+// Frame is a function within an inline stack, along with the position within it
+type Frame struct {
+	Function string
+	File     string
+	Line     int
 }
 
 // Run performs analysis over data read from the supplied reader and returns potential strings
@@ -36,7 +55,7 @@ func Run(r io.ReaderAt, opts ...Option) ([]Result, error) {
 		o(runOptions)
 	}
 
-	f, err := exe.New(r)
+	f, err := exe.New(r, exe.WithDebugDir(runOptions.debugDir), exe.WithWarnings(runOptions.warnings))
 	if err != nil {
 		return nil, fmt.Errorf("invalid file: %w", err)
 	}
@@ -63,59 +82,165 @@ func Run(r io.ReaderAt, opts ...Option) ([]Result, error) {
 		return nil, fmt.Errorf("failed to analyse statictmp: %w", err)
 	}
 
+	// search for comparisons against strings located above
+	candidates3, err := analysis.EvaluateComparisons(f, append(candidates1, candidates2...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyse comparisons: %w", err)
+	}
+
 	// merge candidates
-	candidates := dedupeCandidates(append(candidates1, candidates2...))
+	candidates := dedupeCandidates(append(append(candidates1, candidates2...), candidates3...))
 
 	return buildResults(candidates, f, runOptions)
 }
 
+// FindReferences locates instructions that reference any of the supplied addresses, returning the references keyed
+// by address. Unlike Run, the addresses need not hold strings; any instruction materialising the address is included.
+func FindReferences(r io.ReaderAt, addrs []uint64) (map[uint64][]Reference, error) {
+	f, err := exe.New(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file: %w", err)
+	}
+
+	refAddrs, err := analysis.FindAddressReferences(f, addrs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyse instructions: %w", err)
+	}
+
+	lines, err := newLineResolver(f, LinesPCLN)
+	if err != nil {
+		return nil, err
+	}
+
+	// results are used as carriers, so that references are enriched in the same way as those from Run
+	results := make([]Result, 0, len(refAddrs))
+	for addr, refs := range refAddrs {
+		res := Result{Addr: addr}
+		for _, refAddr := range refs {
+			file, line := lines(refAddr)
+			res.Refs = append(res.Refs, Reference{Addr: refAddr, File: file, Line: line, ArgSlot: -1, FieldOffset: -1})
+		}
+		results = append(results, res)
+	}
+	results, err = enrichWithSymbols(results, f, newInlineTable(f, lines))
+	if err != nil {
+		return nil, err
+	}
+	results = enrichWithModules(results, f)
+
+	found := make(map[uint64][]Reference, len(results))
+	for _, res := range results {
+		found[res.Addr] = res.Refs
+	}
+	return found, nil
+}
+
 func buildResults(candidates []analysis.Candidate, f *exe.File, opts *RunOptions) ([]Result, error) {
 	sect, err := f.RODataSection()
 	if err != nil {
 		return nil, err
 	}
 
-	symtab, err := createSymtab(f)
+	source := opts.lineSource
+	if source == "" {
+		source = LinesPCLN
+	}
+	lines, err := newLineResolver(f, source)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create symtab: %w", err)
+		return nil, err
 	}
+	inl := newInlineTable(f, lines)
+	targets := newTargetResolver(f, inl)
 
 	results := make([]Result, 0, len(candidates))
 	for _, candidate := range candidates {
-		if !sect.AddrRange.Contains(candidate.Addr) || !sect.AddrRange.Contains(candidate.Addr+candidate.Len) {
-			continue // ignore if the address isn't in __rodata
-		}
-		if candidate.Len == 0 {
-			continue // ignore empty strings - all observed cases are false positives (real empty strings manifest differently)
-		}
-		buf := make([]byte, candidate.Len)
-		if _, err := sect.ReadAt(buf, int64(candidate.Addr-sect.AddrRange.Start)); err != nil {
-			return nil, fmt.Errorf("failed to read data: %w", err)
+		value, ok, err := readValue(sect, candidate, opts.permitNulls)
+		if err != nil {
+			return nil, err
 		}
-		if !opts.permitNulls && bytes.IndexByte(buf, 0x00) != -1 {
-			continue // string contains nulls, ignore
+		if !ok {
+			continue
 		}
-		res := Result{
+		results = append(results, Result{
 			Addr:  candidate.Addr,
-			Value: string(buf),
-		}
-		for _, addr := range candidate.RefAddrs {
-			file, line, _ := symtab.PCToLine(addr)
-			ref := Reference{
-				Addr: addr,
-				File: file,
-				Line: line,
-			}
-			res.Refs = append(res.Refs, ref)
-		}
-		results = append(results, res)
+			Value: value,
+			Refs:  newReferences(candidate, lines, targets),
+		})
 	}
 
 	sort.Slice(results, func(i, j int) bool {
 		return results[i].Addr < results[j].Addr
 	})
 
-	return enrichWithSymbols(results, f)
+	results, err = enrichWithSymbols(results, f, inl)
+	if err != nil {
+		return nil, err
+	}
+	results = enrichWithModules(results, f)
+	return filterResults(results, opts), nil
+}
+
+// readValue reads the string value of a candidate from the read-only data section, reporting not ok for candidates
+// that are not plausible strings
+func readValue(sect exe.Section, candidate analysis.Candidate, permitNulls bool) (string, bool, error) {
+	if !sect.AddrRange.Contains(candidate.Addr) || !sect.AddrRange.Contains(candidate.Addr+candidate.Len) {
+		return "", false, nil // ignore if the address isn't in __rodata
+	}
+	if candidate.Len == 0 {
+		return "", false, nil // ignore empty strings - all observed cases are false positives (real empty strings manifest differently)
+	}
+	buf := make([]byte, candidate.Len)
+	if _, err := sect.ReadAt(buf, int64(candidate.Addr-sect.AddrRange.Start)); err != nil {
+		return "", false, fmt.Errorf("failed to read data: %w", err)
+	}
+	if !permitNulls && bytes.IndexByte(buf, 0x00) != -1 {
+		return "", false, nil // string contains nulls, ignore
+	}
+	return string(buf), true, nil
+}
+
+// newReferences creates a reference for each address the candidate is referenced from, along with the line making
+// the reference, and the variable or field the string is assigned to (where targets are resolvable)
+func newReferences(candidate analysis.Candidate, lines lineResolver, targets *dwarfvar.Resolver) []Reference {
+	var refs []Reference
+	for _, addr := range candidate.RefAddrs {
+		file, line := lines(addr)
+		ref := Reference{
+			Addr:        addr,
+			File:        file,
+			Line:        line,
+			ArgSlot:     -1,
+			FieldOffset: -1,
+		}
+		if slot, found := candidate.ArgSlots[addr]; found {
+			ref.ArgSlot = slot
+		}
+		if offset, found := candidate.FieldOffsets[addr]; found {
+			ref.FieldOffset = offset
+		}
+		if dest, found := candidate.Destinations[addr]; found && targets != nil {
+			if target, ok := targets.Resolve(dest); ok {
+				ref.Target, ref.TargetType = target.Expr, target.Type
+			}
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// filterResults retains the references made from the module & scopes requested, if any
+func filterResults(results []Result, opts *RunOptions) []Result {
+	if opts.module != "" {
+		results = filterReferences(results, func(ref Reference) bool {
+			return ref.Module == opts.module
+		})
+	}
+	if len(opts.scopes) > 0 {
+		results = filterReferences(results, func(ref Reference) bool {
+			return opts.scopes[ref.Scope]
+		})
+	}
+	return results
 }
 
 func dedupeCandidates(candidates []analysis.Candidate) []analysis.Candidate {
@@ -123,6 +248,24 @@ func dedupeCandidates(candidates []analysis.Candidate) []analysis.Candidate {
 	for _, res := range candidates {
 		if dupe, found := addrToRes[res.Addr]; found {
 			dupe.RefAddrs = append(dupe.RefAddrs, res.RefAddrs...)
+			for addr, slot := range res.ArgSlots {
+				if dupe.ArgSlots == nil {
+					dupe.ArgSlots = make(map[uint64]int)
+				}
+				dupe.ArgSlots[addr] = slot
+			}
+			for addr, offset := range res.FieldOffsets {
+				if dupe.FieldOffsets == nil {
+					dupe.FieldOffsets = make(map[uint64]int64)
+				}
+				dupe.FieldOffsets[addr] = offset
+			}
+			for addr, dest := range res.Destinations {
+				if dupe.Destinations == nil {
+					dupe.Destinations = make(map[uint64]analysis.Destination)
+				}
+				dupe.Destinations[addr] = dest
+			}
 			addrToRes[res.Addr] = dupe
 		} else {
 			addrToRes[res.Addr] = res
@@ -135,7 +278,30 @@ func dedupeCandidates(candidates []analysis.Candidate) []analysis.Candidate {
 	return deduped
 }
 
-func enrichWithSymbols(results []Result, f *exe.File) ([]Result, error) {
+// newInlineTable prepares to decode inline stacks. This is best effort, since older & unusual binaries may not be
+// decodable, in which case nil is returned.
+func newInlineTable(f *exe.File, lines lineResolver) *inline.Table {
+	inl, err := inline.New(f, lines)
+	if err != nil {
+		return nil
+	}
+	return inl
+}
+
+// newTargetResolver prepares to name the variables & fields strings are assigned to. This requires DWARF, so nil is
+// returned for stripped binaries (as it is if the inline table, which provides frame sizes, is unavailable).
+func newTargetResolver(f *exe.File, inl *inline.Table) *dwarfvar.Resolver {
+	if inl == nil {
+		return nil
+	}
+	targets, err := dwarfvar.New(f, inl.SPDelta)
+	if err != nil {
+		return nil
+	}
+	return targets
+}
+
+func enrichWithSymbols(results []Result, f *exe.File, inl *inline.Table) ([]Result, error) {
 	// extract reference addresses
 	addrs := make([]uint64, 0)
 	for _, res := range results {
@@ -144,6 +310,22 @@ func enrichWithSymbols(results []Result, f *exe.File) ([]Result, error) {
 		}
 	}
 
+	// locate the calls following each reference
+	callees, err := analysis.FindCallees(f, addrs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find callees: %w", err)
+	}
+
+	// callees are function entry points, so are resolved against the function table by exact address
+	funcs, err := f.Functions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read functions: %w", err)
+	}
+	funcNames := make(map[uint64]string, len(funcs))
+	for _, fn := range funcs {
+		funcNames[fn.AddrRange.Start] = fn.Name
+	}
+
 	// resolve symbols for all addresses
 	syms, err := f.SymbolsForAddresses(addrs)
 	if err != nil {
@@ -156,32 +338,22 @@ func enrichWithSymbols(results []Result, f *exe.File) ([]Result, error) {
 			if sym, found := syms[ref.Addr]; found {
 				ref.SymbolName = sym.Name
 				ref.SymbolOffset = int(ref.Addr) - int(sym.AddrRange.Start)
-				res.Refs[j] = ref
 			}
+			if call, found := callees[ref.Addr]; found {
+				ref.Callee = funcNames[call.Target]
+				ref.CallAddr = call.Addr
+			}
+			if inl != nil {
+				if stack := inl.Stack(ref.Addr); len(stack) > 1 {
+					ref.Inlined = make([]Frame, 0, len(stack))
+					for _, fr := range stack {
+						ref.Inlined = append(ref.Inlined, Frame(fr))
+					}
+				}
+			}
+			res.Refs[j] = ref
 		}
 		results[i] = res
 	}
 	return results, nil
 }
-
-func createSymtab(f *exe.File) (*gosym.Table, error) {
-	txt, err := f.TextSection()
-	if err != nil {
-		return nil, err
-	}
-
-	pclntab, err := f.PCLNTabSection()
-	if err != nil {
-		return nil, err
-	}
-
-	data, err := pclntab.Data()
-	if err != nil {
-		return nil, err
-	}
-
-	// `gosym.LineTable` doesn't provide file information. So we have to wrap it with `gosym.Table`, which does. Not
-	// need to provide symtab data - and in fact, the symtab section is zero size in Mach-O binaries, so I'm assuming
-	// it is no longer populated.
-	return gosym.NewTable(nil, gosym.NewLineTable(data, txt.AddrRange.Start))
-}
@@ -0,0 +1,62 @@
+package scan
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Scope classifies the code a reference is made from
+type Scope string
+
+// Scopes a reference may belong to
+const (
+	ScopeMain Scope = "main" // the main module
+	ScopeDeps Scope = "deps" // dependencies of the main module
+	ScopeStd  Scope = "std"  // the standard library, including the runtime
+)
+
+// ParseScope parses the name of a scope
+func ParseScope(s string) (Scope, error) {
+	switch scope := Scope(s); scope {
+	case ScopeMain, ScopeDeps, ScopeStd:
+		return scope, nil
+	default:
+		return "", fmt.Errorf("invalid scope: %s", s)
+	}
+}
+
+// scope classifies a reference. The module its file belongs to is used where known, falling back to the package of
+// the symbol making the reference (e.g. where the file is absent or compiler generated).
+func (r *moduleResolver) scope(ref Reference) Scope {
+	switch ref.Module {
+	case ModuleStdlib:
+		return ScopeStd
+	case ModuleMain:
+		return ScopeMain
+	case "":
+	default:
+		return ScopeDeps
+	}
+
+	pkg := symbolPackage(ref.SymbolName)
+	switch {
+	case pkg == "main":
+		return ScopeMain
+	case r.bi != nil && r.bi.Main.Path != "" && (pkg == r.bi.Main.Path || strings.HasPrefix(pkg, r.bi.Main.Path+"/")):
+		return ScopeMain
+	case strings.Contains(strings.SplitN(pkg, "/", 2)[0], "."):
+		return ScopeDeps
+	default:
+		// includes compiler generated symbols (e.g. type:.eq.*), which belong with the runtime
+		return ScopeStd
+	}
+}
+
+// symbolPackage returns the import path of the package a symbol belongs to, e.g. net/http for net/http.(*Client).Do
+func symbolPackage(name string) string {
+	slash := strings.LastIndex(name, "/")
+	if dot := strings.Index(name[slash+1:], "."); dot >= 0 {
+		return name[:slash+1+dot]
+	}
+	return name
+}
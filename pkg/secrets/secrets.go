@@ -0,0 +1,175 @@
+// Package secrets detects likely hard-coded credentials amongst scan results, by matching each string against a set
+// of rules. Each rule is a regular expression, optionally combined with a minimum Shannon entropy.
+package secrets
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+
+	"github.com/nick-jones/gost/pkg/report"
+	"github.com/nick-jones/gost/pkg/scan"
+)
+
+//go:embed rules.json
+var defaultRules []byte
+
+// Rule identifies a kind of secret
+type Rule struct {
+	ID          string  `json:"id"`
+	Description string  `json:"description"`
+	Regex       string  `json:"regex"`
+	MinEntropy  float64 `json:"min_entropy,omitempty"` // minimum Shannon entropy of the match, in bits per byte
+	Exclude     string  `json:"exclude,omitempty"`     // matches are discarded if this also matches
+	re          *regexp.Regexp
+	exclude     *regexp.Regexp
+}
+
+// Ruleset is a collection of rules, in the form read from rule files
+type Ruleset struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Finding is a string matching a rule, along with the locations referencing it
+type Finding struct {
+	Rule        string     `json:"rule"`
+	Description string     `json:"description"`
+	Value       string     `json:"value"`
+	Match       string     `json:"match"`
+	Entropy     float64    `json:"entropy"`
+	Locations   []Location `json:"locations"`
+}
+
+// Location is where a string is referenced from
+type Location struct {
+	Symbol string `json:"symbol,omitempty"`
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+}
+
+// DefaultRuleset returns the built-in rules, which cover cloud provider keys, common tokens, private key headers,
+// connection strings with passwords and high entropy strings
+func DefaultRuleset() (Ruleset, error) {
+	return parseRuleset(defaultRules)
+}
+
+// LoadRuleset reads rules from a JSON file, in the same form as the built-in rules
+func LoadRuleset(path string) (Ruleset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Ruleset{}, err
+	}
+	return parseRuleset(data)
+}
+
+// parseRuleset decodes and compiles rules
+func parseRuleset(data []byte) (Ruleset, error) {
+	var rs Ruleset
+	if err := json.Unmarshal(data, &rs); err != nil {
+		return Ruleset{}, fmt.Errorf("failed to decode rules: %w", err)
+	}
+	for i, r := range rs.Rules {
+		if r.ID == "" {
+			return Ruleset{}, fmt.Errorf("rule %d has no id", i)
+		}
+		re, err := regexp.Compile(r.Regex)
+		if err != nil {
+			return Ruleset{}, fmt.Errorf("invalid regex for rule %s: %w", r.ID, err)
+		}
+		rs.Rules[i].re = re
+		if r.Exclude != "" {
+			if rs.Rules[i].exclude, err = regexp.Compile(r.Exclude); err != nil {
+				return Ruleset{}, fmt.Errorf("invalid exclude regex for rule %s: %w", r.ID, err)
+			}
+		}
+	}
+	return rs, nil
+}
+
+// Scan matches each result against the rules. A string is reported against the first rule it matches. Findings are
+// ordered by rule, then by value.
+func (rs Ruleset) Scan(results []scan.Result) []Finding {
+	findings := make([]Finding, 0)
+	for _, res := range results {
+		for _, r := range rs.Rules {
+			match := r.re.FindString(res.Value)
+			if match == "" {
+				continue
+			}
+			if r.exclude != nil && r.exclude.MatchString(match) {
+				continue
+			}
+			entropy := Entropy(match)
+			if entropy < r.MinEntropy {
+				continue
+			}
+			locs := make([]Location, len(res.Refs))
+			for i, ref := range res.Refs {
+				locs[i] = Location{Symbol: ref.SymbolName, File: ref.File, Line: ref.Line}
+			}
+			findings = append(findings, Finding{
+				Rule:        r.ID,
+				Description: r.Description,
+				Value:       res.Value,
+				Match:       match,
+				Entropy:     math.Round(entropy*100) / 100,
+				Locations:   locs,
+			})
+			break
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Rule != findings[j].Rule {
+			return findings[i].Rule < findings[j].Rule
+		}
+		return findings[i].Value < findings[j].Value
+	})
+	return findings
+}
+
+// Entropy returns the Shannon entropy of the string, in bits per byte
+func Entropy(s string) float64 {
+	if len(s) == 0 {
+		return 0
+	}
+	var counts [256]int
+	for i := 0; i < len(s); i++ {
+		counts[s[i]]++
+	}
+	var entropy float64
+	for _, c := range counts {
+		if c == 0 {
+			continue
+		}
+		p := float64(c) / float64(len(s))
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// WriteText writes one line per finding location, in the form [rule] "value" → file:line (symbol)
+func WriteText(w io.Writer, findings []Finding) error {
+	for _, f := range findings {
+		if len(f.Locations) == 0 {
+			if _, err := fmt.Fprintf(w, "[%s] %q\n", f.Rule, f.Value); err != nil {
+				return err
+			}
+		}
+		for _, loc := range f.Locations {
+			if _, err := fmt.Fprintf(w, "[%s] %q → %s:%d (%s)\n", f.Rule, f.Value, loc.File, loc.Line, loc.Symbol); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the findings as a JSON document, for consumption by CI tooling
+func WriteJSON(w io.Writer, findings []Finding) error {
+	return report.WriteVersionedJSON(w, "findings", findings)
+}
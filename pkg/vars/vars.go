@@ -0,0 +1,268 @@
+// Package vars recovers the values of package-level string variables that are initialised statically, which includes
+// those set at link time with -ldflags "-X pkg.var=value". Such values are held in string headers within the data
+// sections rather than materialised by instructions, so are not found by scanning code. Variables are located by
+// symbol. Stripped binaries (unless their separate debug file is supplied) are instead scanned for string headers
+// pointing into the read-only data, which are reported without names.
+package vars
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/nick-jones/gost/internal/address"
+	"github.com/nick-jones/gost/internal/exe"
+	"github.com/nick-jones/gost/pkg/report"
+)
+
+// ErrNoSymbols is returned when the binary has no symbol table (e.g. it was stripped, and no debug file was supplied),
+// and has no read-only data section either, so variables cannot be found
+var ErrNoSymbols = errors.New("binary has no symbols")
+
+// maxValueLen bounds the length of values considered, so that headers are rejected without reading garbage
+const maxValueLen = 1 << 16
+
+// injectedSuffix is appended to the name of a variable to form the name of the symbol holding a value set by -X
+const injectedSuffix = ".str"
+
+// Var is a package-level string variable along with its initial value
+type Var struct {
+	Name     string         `json:"name"` // fully qualified, e.g. main.version, or empty if unknown
+	Addr     report.Address `json:"address"`
+	Value    string         `json:"value"`
+	Injected bool           `json:"injected,omitempty"` // set at link time with -X
+}
+
+// Package returns the import path of the package the variable belongs to
+func (v Var) Package() string {
+	slash := strings.LastIndex(v.Name, "/")
+	if dot := strings.Index(v.Name[slash+1:], "."); dot >= 0 {
+		return v.Name[:slash+1+dot]
+	}
+	return ""
+}
+
+// StandardLibrary returns true if the variable belongs to a standard library package. Like the go tool, this treats
+// import paths whose first element contains no dot as standard, with the exception of main. Variables without names
+// are not considered standard, since their package is unknown.
+func (v Var) StandardLibrary() bool {
+	pkg := v.Package()
+	if pkg == "" {
+		return false
+	}
+	first := strings.SplitN(pkg, "/", 2)[0]
+	return pkg != "main" && !strings.Contains(first, ".")
+}
+
+// Option configures Find
+type Option func(*options)
+
+type options struct {
+	debugDir string
+	warnings io.Writer
+}
+
+// WithDebugDir supplies a directory holding separate debug files, so that variables of stripped ELF binaries can be
+// located using the symbols of the matching debug file
+func WithDebugDir(dir string) Option {
+	return func(o *options) {
+		o.debugDir = dir
+	}
+}
+
+// WithWarnings supplies a writer for non-fatal problems, such as a debug directory holding no debug file matching the
+// binary
+func WithWarnings(w io.Writer) Option {
+	return func(o *options) {
+		o.warnings = w
+	}
+}
+
+// Find reads the string header of every variable symbol within the writable data sections, returning those that hold
+// a plausible string. Variables are ordered as the symbol table is, by address. Binaries without symbols are searched
+// for string headers instead (see findUnnamed).
+func Find(r io.ReaderAt, opts ...Option) ([]Var, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	f, err := exe.New(r, exe.WithDebugDir(o.debugDir), exe.WithWarnings(o.warnings))
+	if err != nil {
+		return nil, fmt.Errorf("invalid file: %w", err)
+	}
+	syms, err := f.Symbols()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read symbols: %w", err)
+	}
+	if len(syms) == 0 {
+		return findUnnamed(f)
+	}
+
+	names := make(map[string]bool, len(syms))
+	for _, sym := range syms {
+		names[sym.Name] = true
+	}
+
+	sects, err := f.LoadedSections(func(s exe.Section) bool {
+		return isWritableDataSection(s.Name)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sections: %w", err)
+	}
+
+	bo := f.ByteOrder()
+	found := make([]Var, 0)
+	for _, sym := range syms {
+		if sym.AddrRange.Start%8 != 0 || !isVariableName(sym.Name) {
+			continue
+		}
+		if sym.AddrRange.Size() > 16 {
+			continue // larger than a string header (e.g. an array of strings); where sizes are guessed, ranges end 1 short
+		}
+		header, ok := headerAt(sects, sym.AddrRange.Start)
+		if !ok {
+			continue
+		}
+		ptr, length := bo.Uint64(header), bo.Uint64(header[8:])
+		if ptr == 0 || length == 0 || length > maxValueLen {
+			continue
+		}
+		value, ok := readString(f, ptr, length)
+		if !ok {
+			continue
+		}
+		found = append(found, Var{
+			Name:     sym.Name,
+			Addr:     report.Address(sym.AddrRange.Start),
+			Value:    value,
+			Injected: names[sym.Name+injectedSuffix],
+		})
+	}
+	return found, nil
+}
+
+// findUnnamed searches the writable data sections of a binary without symbols for string headers, i.e. a pointer into
+// the read-only data followed by a length that keeps within it. This covers the string table, along with values set by
+// -X, which the linker places after it. Without symbols, nothing distinguishes a variable from any other statically
+// initialised header (e.g. an element of an array of strings), so all are reported, by address.
+func findUnnamed(f *exe.File) ([]Var, error) {
+	rodata, err := f.RODataSection()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoSymbols, err)
+	}
+	strRange := rodata.AddrRange
+	sects, err := f.LoadedSections(func(s exe.Section) bool {
+		return isWritableDataSection(s.Name)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sections: %w", err)
+	}
+
+	bo := f.ByteOrder()
+	found := make([]Var, 0)
+	for _, sect := range sects {
+		data := sect.Bytes
+		// headers are pointer aligned
+		start := (8 - sect.AddrRange.Start%8) % 8
+		for off := start; off+16 <= uint64(len(data)); off += 8 {
+			ptr, length := bo.Uint64(data[off:]), bo.Uint64(data[off+8:])
+			if length == 0 || length > maxValueLen || !strRange.Contains(ptr) || ptr+length > strRange.End {
+				continue
+			}
+			value, ok := readString(f, ptr, length)
+			if !ok {
+				continue
+			}
+			found = append(found, Var{
+				Addr:  report.Address(sect.AddrRange.Start + off),
+				Value: value,
+			})
+		}
+	}
+	return found, nil
+}
+
+// headerAt returns the string header held at the supplied address, if it lies within one of the sections
+func headerAt(sects []exe.SectionData, addr uint64) ([]byte, bool) {
+	for _, sect := range sects {
+		if header, ok := sect.At(addr, 16); ok {
+			return header, true
+		}
+	}
+	return nil, false
+}
+
+// isVariableName returns true for symbol names that may be package-level variables, i.e. a package path followed by
+// an identifier. Compiler generated symbols (e.g. go:*, type:*, *.str, closures & methods) are rejected.
+func isVariableName(name string) bool {
+	if strings.HasPrefix(name, "go:") || strings.HasPrefix(name, "type:") || strings.HasSuffix(name, injectedSuffix) {
+		return false
+	}
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot <= 0 {
+		return false
+	}
+	ident := name[slash+1+dot+1:]
+	if ident == "" || ident == "_" {
+		return false
+	}
+	for i, r := range ident {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// isWritableDataSection returns true for sections holding initialised variables (e.g. .data, .noptrdata, __data)
+func isWritableDataSection(name string) bool {
+	name = strings.ToLower(name)
+	return strings.Contains(name, "data") && !strings.Contains(name, "rodata") && !strings.Contains(name, "rdata") &&
+		!strings.Contains(name, "rel.ro")
+}
+
+// readString reads the string at the supplied address, reporting not ok if it is unreadable or does not look like
+// text
+func readString(f *exe.File, addr, length uint64) (string, bool) {
+	sect, err := f.SectionContainingRange(address.Range{Start: addr, End: addr + length})
+	if err != nil {
+		return "", false
+	}
+	buf := make([]byte, length)
+	if _, err := sect.ReadAt(buf, int64(addr-sect.AddrRange.Start)); err != nil {
+		return "", false
+	}
+	if !utf8.Valid(buf) || strings.IndexByte(string(buf), 0x00) != -1 {
+		return "", false
+	}
+	return string(buf), true
+}
+
+// WriteText writes each variable as name = "value", marking those set with -X. Variables without names are written
+// with their address in place of the name.
+func WriteText(w io.Writer, vars []Var) error {
+	for _, v := range vars {
+		name := v.Name
+		if name == "" {
+			name = fmt.Sprintf("%#x", uint64(v.Addr))
+		}
+		line := fmt.Sprintf("%s = %q", name, v.Value)
+		if v.Injected {
+			line += " (-X)"
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the variables as a JSON document, listed under "vars"
+func WriteJSON(w io.Writer, vars []Var) error {
+	return report.WriteVersionedJSON(w, "vars", vars)
+}
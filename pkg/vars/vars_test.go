@@ -0,0 +1,58 @@
+package vars_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nick-jones/gost/internal/testbin"
+	"github.com/nick-jones/gost/pkg/vars"
+)
+
+func TestVar_Package(t *testing.T) {
+	assert.Equal(t, "main", vars.Var{Name: "main.version"}.Package())
+	assert.Equal(t, "runtime", vars.Var{Name: "runtime.buildVersion"}.Package())
+	assert.Equal(t, "example.com/app/internal/build", vars.Var{Name: "example.com/app/internal/build.Commit"}.Package())
+}
+
+func TestVar_StandardLibrary(t *testing.T) {
+	assert.False(t, vars.Var{Name: "main.version"}.StandardLibrary())
+	assert.True(t, vars.Var{Name: "runtime.buildVersion"}.StandardLibrary())
+	assert.True(t, vars.Var{Name: "net/http.DefaultUserAgent"}.StandardLibrary())
+	assert.False(t, vars.Var{Name: "example.com/app/internal/build.Commit"}.StandardLibrary())
+}
+
+func TestWriteText(t *testing.T) {
+	found := []vars.Var{
+		{Name: "main.greeting", Value: "hello"},
+		{Name: "example.com/app/internal/build.Version", Value: "v1.2.3", Injected: true},
+		{Addr: 0x56c240, Value: "v1.2.3"},
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, vars.WriteText(buf, found))
+	expected := `main.greeting = "hello"
+example.com/app/internal/build.Version = "v1.2.3" (-X)
+0x56c240 = "v1.2.3"
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestFind_Stripped(t *testing.T) {
+	src := "package main\n\nimport \"fmt\"\n\nvar version = \"dev\"\n\nfunc main() { fmt.Println(version) }\n"
+	f := testbin.Open(t, map[string]string{"main.go": src}, "-ldflags", "-s -w -X main.version=v1.2.3")
+
+	found, err := vars.Find(f)
+	require.NoError(t, err)
+
+	// without symbols, the value is found but its name is not
+	var values []string
+	for _, v := range found {
+		assert.Empty(t, v.Name)
+		assert.False(t, v.StandardLibrary())
+		values = append(values, v.Value)
+	}
+	assert.Contains(t, values, "v1.2.3")
+}
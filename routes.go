@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/nick-jones/gost/pkg/inventory"
+)
+
+var routesCommand = &cli.Command{
+	Name:      "routes",
+	Usage:     "list the HTTP routes registered with net/http",
+	ArgsUsage: "<binary>",
+	Flags: append([]cli.Flag{
+		formatFlag,
+	}, scanFlags("all")...),
+	Action: runRoutes,
+}
+
+func runRoutes(c *cli.Context) error {
+	write, err := formatWriter(c, inventory.WriteRoutesText, inventory.WriteRoutesJSON)
+	if err != nil {
+		return err
+	}
+
+	results, err := scanFile(c, c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	return write(os.Stdout, inventory.Routes(results))
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/nick-jones/gost/pkg/secrets"
+)
+
+var secretsCommand = &cli.Command{
+	Name:      "secrets",
+	Usage:     "report likely hard-coded credentials, exiting with status 1 if any are found",
+	ArgsUsage: "<binary>",
+	Flags: append([]cli.Flag{
+		formatFlag,
+		&cli.StringFlag{
+			Name:  "rules",
+			Usage: "path to a JSON ruleset, used in place of the built-in rules",
+		},
+	}, scanFlags("all")...),
+	Action: runSecrets,
+}
+
+func runSecrets(c *cli.Context) error {
+	write, err := formatWriter(c, secrets.WriteText, secrets.WriteJSON)
+	if err != nil {
+		return err
+	}
+
+	rules, err := secrets.DefaultRuleset()
+	if path := c.String("rules"); path != "" {
+		rules, err = secrets.LoadRuleset(path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load rules: %w", err)
+	}
+
+	results, err := scanFile(c, c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	findings := rules.Scan(results)
+	if err := write(os.Stdout, findings); err != nil {
+		return err
+	}
+	if len(findings) > 0 {
+		return cli.Exit(fmt.Sprintf("%d potential secrets found", len(findings)), 1)
+	}
+	return nil
+}
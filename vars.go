@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/nick-jones/gost/pkg/vars"
+)
+
+var varsCommand = &cli.Command{
+	Name:      "vars",
+	Usage:     "list package-level string variables and their values, including those set with -ldflags -X",
+	ArgsUsage: "<binary>",
+	Flags: []cli.Flag{
+		formatFlag,
+		&cli.BoolFlag{
+			Name:  "injected",
+			Usage: "only list variables set with -ldflags -X",
+		},
+		&cli.BoolFlag{
+			Name:  "std",
+			Usage: "include variables of standard library packages",
+		},
+		debugDirFlag,
+	},
+	Action: runVars,
+}
+
+func runVars(c *cli.Context) error {
+	write, err := formatWriter(c, vars.WriteText, vars.WriteJSON)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(c.Args().First())
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	found, err := vars.Find(f, vars.WithDebugDir(c.String("debug-dir")), vars.WithWarnings(c.App.ErrWriter))
+	if err != nil {
+		return fmt.Errorf("failed to find variables: %w", err)
+	}
+
+	filtered := make([]vars.Var, 0, len(found))
+	for _, v := range found {
+		if c.Bool("injected") && !v.Injected {
+			continue
+		}
+		if !c.Bool("std") && v.StandardLibrary() {
+			continue
+		}
+		filtered = append(filtered, v)
+	}
+	return write(os.Stdout, filtered)
+}